@@ -1,25 +1,192 @@
+// hl7Parser reads HL7 messages from a file or stdin and prints the value at
+// a path, or each message as JSON.
+//
+//	hl7Parser -file adt.hl7 -path PID-5.2
+//	cat adt.hl7 | hl7Parser -path PID-3[*].1
+//	hl7Parser -file adt.hl7 -json
+//	hl7Parser -file capture.mllp -format mllp -path MSH-10
+//
+// The input can be a single raw message, MLLP frames or a batch file, see
+// -format. By default the format is detected: input starting with the MLLP
+// start block (0x0B) is read as frames, input starting with an FHS or BHS
+// segment or holding more than one MSH segment is split as a batch, anything
+// else is one raw message. Every message of the input is read, in order.
+//
+// A path with a wildcard prints every value it selects on its own line. On
+// invalid input the error is printed to stderr and the exit status is 1, 2
+// for invalid flags.
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/amaster507/goschemaless/hl7"
+	"github.com/amaster507/goschemaless/hl7/mllp"
+)
+
+// The input formats of -format.
+const (
+	formatAuto  = "auto"
+	formatRaw   = "raw"
+	formatMLLP  = "mllp"
+	formatBatch = "batch"
 )
 
 func main() {
-	// Example usage
-	message := "MSH|^~\\&|SendingApp|SendingFac|ReceivingApp|ReceivingFac|20240101120000||ADT^A01|123|P|2.5\rPID|1||PatientID|||Doe^John"
-	path := hl7.HL7Path{
-		Segment:      "PID",
-		SegmentIndex: 1,
-		Field:        5,
-		Component:    2,
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hl7Parser", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", "", "read the message from `file` instead of stdin")
+	path := flags.String("path", "", "print the value at `path`, e.g. PID-5.2")
+	asJSON := flags.Bool("json", false, "print the whole message as JSON")
+	format := flags.String("format", formatAuto, "read the input as `format`: auto, raw, mllp or batch")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+	if (*path == "") == !*asJSON {
+		fmt.Fprintln(stderr, "exactly one of -path or -json is required")
+		flags.Usage()
+		return 2
+	}
+	switch *format {
+	case formatAuto, formatRaw, formatMLLP, formatBatch:
+	default:
+		fmt.Fprintf(stderr, "unknown format %q, expected auto, raw, mllp or batch\n", *format)
+		flags.Usage()
+		return 2
 	}
 
-	result, err := hl7.AbstractHL7(message, path)
+	if err := extract(*file, *path, *format, *asJSON, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "hl7Parser: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func extract(file, path, format string, asJSON bool, stdin io.Reader, stdout io.Writer) error {
+	input := stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+	messages, err := splitInput(data, format)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
+		return err
+	}
+	if len(messages) == 0 {
+		return errors.New("no message to read")
+	}
+
+	var p hl7.HL7Path
+	if !asJSON {
+		if p, err = hl7.ParsePath(path); err != nil {
+			return err
+		}
+	}
+	for _, message := range messages {
+		if err := printMessage(message, p, asJSON, stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitInput returns the messages of data read in format, see -format.
+func splitInput(data []byte, format string) ([]string, error) {
+	if format == formatAuto {
+		format = detectFormat(data)
+	}
+	switch format {
+	case formatMLLP:
+		var messages []string
+		r := mllp.NewReader(bytes.NewReader(data))
+		for {
+			message, err := r.ReadMessage()
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+	case formatBatch:
+		return hl7.SplitBatch(string(data))
+	default:
+		if len(data) == 0 {
+			return nil, nil
+		}
+		return []string{string(data)}, nil
+	}
+}
+
+// detectFormat guesses the format of data from how it starts, after any
+// line breaks, and from how many messages it holds.
+func detectFormat(data []byte) string {
+	data = bytes.TrimLeft(data, "\r\n")
+	switch {
+	case len(data) > 0 && data[0] == mllp.StartBlock:
+		return formatMLLP
+	case bytes.HasPrefix(data, []byte("FHS")) || bytes.HasPrefix(data, []byte("BHS")):
+		return formatBatch
+	case countMSH(data) > 1:
+		// messages put one after the other without batch headers
+		return formatBatch
+	default:
+		return formatRaw
+	}
+}
+
+// countMSH returns the number of MSH segments in data.
+func countMSH(data []byte) int {
+	count := 0
+	for _, line := range bytes.FieldsFunc(data, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if bytes.HasPrefix(line, []byte("MSH")) {
+			count++
+		}
+	}
+	return count
+}
+
+// printMessage writes message as JSON, or the values at p in it one per line.
+func printMessage(message string, p hl7.HL7Path, asJSON bool, stdout io.Writer) error {
+	if asJSON {
+		out, err := hl7.ToJSON(message)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", out)
+		return err
+	}
+	values, err := hl7.AbstractHL7All(message, p)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		if _, err := fmt.Fprintln(stdout, value); err != nil {
+			return err
+		}
 	}
-	fmt.Printf("Result: %s\n", result)
+	return nil
 }
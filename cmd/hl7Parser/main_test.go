@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amaster507/goschemaless/hl7"
+	"github.com/amaster507/goschemaless/hl7/mllp"
+)
+
+const adt = "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||555-44-4444^^^^SSN~123^^^^MRN||EVERYWOMAN^EVE"
+
+const oru = "MSH|^~\\&|LAB|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.5\rOBX|1|ST|^Body Weight||79"
+
+// framed returns messages framed with MLLP.
+func framed(t *testing.T, messages ...string) string {
+	t.Helper()
+	var b bytes.Buffer
+	w := mllp.NewWriter(&b)
+	for _, message := range messages {
+		if err := w.WriteMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b.String()
+}
+
+func toJSON(t *testing.T, message string) string {
+	t.Helper()
+	out, err := hl7.ToJSON(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out) + "\n"
+}
+
+func batch(messages ...string) string {
+	return "FHS|^~\\&|HIS\rBHS|^~\\&|HIS\r" + strings.Join(messages, "\r") + "\rBTS|2\rFTS|1\r"
+}
+
+func TestRunFormat(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		args   []string
+		input  string
+		stdout string
+		stderr string
+		code   int
+	}{
+		{"auto raw", []string{"-path", "MSH-10"}, adt, "MSG00001\n", "", 0},
+		{"auto mllp", []string{"-path", "MSH-10"}, framed(t, adt, oru), "MSG00001\nMSG00002\n", "", 0},
+		{"auto mllp after line breaks", []string{"-path", "MSH-10"}, "\r\n" + framed(t, adt), "MSG00001\n", "", 0},
+		{"auto batch", []string{"-path", "MSH-10"}, batch(adt, oru), "MSG00001\nMSG00002\n", "", 0},
+		{"auto messages without batch headers", []string{"-path", "MSH-10"}, adt + "\r" + oru, "MSG00001\nMSG00002\n", "", 0},
+		{"auto messages on their own lines", []string{"-path", "MSH-10"}, adt + "\r\n\r\n" + oru + "\r\n", "MSG00001\nMSG00002\n", "", 0},
+		{"auto batch without a file header", []string{"-path", "MSH-10"}, "BHS|^~\\&|HIS\r" + adt + "\rBTS|1", "MSG00001\n", "", 0},
+		{"raw", []string{"-format", "raw", "-path", "PID-5.1"}, adt, "EVERYWOMAN\n", "", 0},
+		{"raw keeps a framed message as it is", []string{"-format", "raw", "-path", "MSH-10"}, framed(t, adt), "", "hl7Parser: invalid HL7 message: must begin with MSH\n", 1},
+		{"mllp", []string{"-format", "mllp", "-path", "OBX-5"}, framed(t, adt, oru), "79\n", "", 0},
+		{"mllp without frames", []string{"-format", "mllp", "-path", "MSH-10"}, adt, "", "hl7Parser: invalid frame: unexpected byte 0x4D before the start block\n", 1},
+		{"mllp truncated", []string{"-format", "mllp", "-path", "MSH-10"}, framed(t, adt)[:20], "", "hl7Parser: truncated frame: stream ended before the end of the frame\n", 1},
+		{"mllp empty", []string{"-format", "mllp", "-path", "MSH-10"}, "", "", "hl7Parser: no message to read\n", 1},
+		{"batch", []string{"-format", "batch", "-path", "MSH-9.1"}, batch(adt, oru), "ADT\nORU\n", "", 0},
+		{"batch of messages without headers", []string{"-format", "batch", "-path", "MSH-10"}, adt + "\r" + oru, "MSG00001\nMSG00002\n", "", 0},
+		{"batch without messages", []string{"-format", "batch", "-path", "MSH-10"}, "FHS|^~\\&\rFTS|0", "", "hl7Parser: no message to read\n", 1},
+		{"json per message", []string{"-format", "mllp", "-json"}, framed(t, adt, oru), toJSON(t, adt) + toJSON(t, oru), "", 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+			if code != test.code {
+				t.Errorf("exit status %d, expected %d, stderr: %s", code, test.code, stderr.String())
+			}
+			if stdout.String() != test.stdout {
+				t.Errorf("stdout %q, expected %q", stdout.String(), test.stdout)
+			}
+			if stderr.String() != test.stderr {
+				t.Errorf("stderr %q, expected %q", stderr.String(), test.stderr)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "adt.hl7")
+	if err := os.WriteFile(file, []byte(adt), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.hl7")
+
+	for _, test := range []struct {
+		name   string
+		args   []string
+		input  string
+		stdout string
+		// stderr is a prefix, usage errors are followed by the usage
+		stderr string
+		code   int
+	}{
+		{"path", []string{"-path", "PID-5.1"}, adt, "EVERYWOMAN\n", "", 0},
+		{"path from a file", []string{"-file", file, "-path", "PID-5.2"}, "", "EVE\n", "", 0},
+		{"wildcard", []string{"-path", "PID-3[*].1"}, adt, "555-44-4444\n123\n", "", 0},
+		{"wildcard over components", []string{"-path", "PID-5.*"}, adt, "EVERYWOMAN\nEVE\n", "", 0},
+		{"json", []string{"-json"}, adt, toJSON(t, adt), "", 0},
+
+		{"path and json", []string{"-json", "-path", "PID-5"}, adt, "", "exactly one of -path or -json is required\n", 2},
+		{"neither path nor json", nil, adt, "", "exactly one of -path or -json is required\n", 2},
+		{"unexpected argument", []string{"-path", "PID-5", "extra"}, adt, "", "unexpected argument \"extra\"\n", 2},
+		{"unknown flag", []string{"-nope"}, adt, "", "flag provided but not defined: -nope\n", 2},
+		{"unknown format", []string{"-format", "xml", "-path", "PID-5"}, adt, "", "unknown format \"xml\", expected auto, raw, mllp or batch\n", 2},
+
+		{"bad message", []string{"-path", "PID-5"}, "PID|1", "", "hl7Parser: invalid HL7 message: must begin with MSH\n", 1},
+		{"bad path", []string{"-path", "PID-"}, adt, "", "hl7Parser: invalid path format", 1},
+		{"missing file", []string{"-file", missing, "-path", "PID-5"}, "", "", "hl7Parser: open " + missing + ": no such file or directory\n", 1},
+		{"empty input", []string{"-path", "PID-5"}, "", "", "hl7Parser: no message to read\n", 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+			if code != test.code {
+				t.Errorf("exit status %d, expected %d, stderr: %s", code, test.code, stderr.String())
+			}
+			if stdout.String() != test.stdout {
+				t.Errorf("stdout %q, expected %q", stdout.String(), test.stdout)
+			}
+			if !strings.HasPrefix(stderr.String(), test.stderr) || (test.stderr == "") != (stderr.Len() == 0) {
+				t.Errorf("stderr %q, expected it to start with %q", stderr.String(), test.stderr)
+			}
+		})
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/amaster507/goschemaless
 
 go 1.22.2
+
+require golang.org/x/text v0.21.0
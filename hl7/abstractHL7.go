@@ -1,80 +1,42 @@
 package hl7
 
 import (
-	"errors"
 	"slices"
 	"strings"
 )
 
+// AbstractHL7 takes an HL7 message and a path, and returns the value at that
+// path in the message.
+//
+// The empty path (the zero HL7Path, or ParsePath("")) is guaranteed to return
+// message exactly as given, byte for byte: segment terminators are not
+// normalized, escapes are not touched and the message is not validated. This
+// makes it safe to use for passthrough routing.
+//
+// MSH-1 and MSH-2 are returned exactly as the message declares them. MSH-2,
+// ^~\& or ^~\&# with a truncation character, holds the repetition and escape
+// characters itself so it is never split by repetition, and AbstractHL7Opts
+// never unescapes, trims or truncates it whatever the options.
 func AbstractHL7(message string, path HL7Path) (string, error) {
 	// just do a check before wasting time parsing the message if the path is invalid
 	if err := path.Validate(); err != nil {
-		return "", err
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: err}
+	}
+	if path.hasWildcard() {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: errWildcardPath}
 	}
-	/**
-	* This function will take an HL7 message and a path, and return the value at that path in the message.
-	* First we need to check that the message is mostly valid and extract the separators
-	* - It must begin with MSH
-	* - It must have a field separator at the 4th character
-	* - It must have separators between the first field separator and the 2nd
-	*   field separator in the MSH segment. Like MSH|...| not MSH||
-	* - The separators must not be reused. Like MSH|^~\&| not MSH|1111|
-	* - There can be a max of 5 separators but the 5th one is not really
-	*   supported here.
-	* - By default, the separators are ^~\&# but they can be interchanged
-	*   dynamically in the MSH segment.
-	 */
-	// if the path is 0 value, return the whole message
+	// if the path is 0 value, return the whole message untouched
 	if path == (HL7Path{}) {
 		return message, nil
 	}
 
-	// validate message begins with MSH
-	if len(message) < 3 || message[:3] != "MSH" {
-		return "", errors.New("invalid HL7 message: must begin with MSH")
-	}
-	// get the next 6 characters after MSH which should be the separators
-	// if there are not 6 characters after MSH, it's an error because the separators must be defined
-	if len(message) < 10 {
-		return "", errors.New("invalid HL7 message: message too short to contain separators and meaningful data")
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return "", &ExtractError{Path: path, Stage: StageHeader, Err: err}
 	}
-	separators := message[3:10]
-	fieldSeparator := separators[0]
 	if path.Segment == "MSH" && path.Field == 1 {
 		// MSH-1 is the field separator itself, so return that if requested
-		return string(fieldSeparator), nil
-	}
-	componentSeparator := separators[1]
-	if componentSeparator == fieldSeparator {
-		return "", errors.New("missing component separator")
-	}
-	repetitionSeparator := separators[2]
-	if repetitionSeparator == fieldSeparator {
-		return "", errors.New("missing repetition separator")
-	}
-	escapeCharacter := separators[3]
-	// if escapeCharacter is the same as the fieldSeparator then it is missing
-	if escapeCharacter == fieldSeparator {
-		return "", errors.New("missing escape character")
-	}
-	subcomponentSeparator := separators[4]
-	if subcomponentSeparator == fieldSeparator {
-		return "", errors.New("missing subcomponent separator")
-	}
-	// there could be a 5th separator we don't care about...
-	// but the separators must end with the field separator again.
-	if separators[5] != fieldSeparator && separators[6] != fieldSeparator {
-		return "", errors.New("unexpected extra separators")
-	}
-
-	// check that all separators are unique
-	separatorsSet := []byte{fieldSeparator, componentSeparator, repetitionSeparator, escapeCharacter, subcomponentSeparator}
-	seen := make(map[byte]bool)
-	for _, sep := range separatorsSet {
-		if seen[sep] {
-			return "", errors.New("separators must be unique")
-		}
-		seen[sep] = true
+		return string(sep.Field), nil
 	}
 
 	// if we made it here, the message is valid enough to parse the path and
@@ -83,19 +45,32 @@ func AbstractHL7(message string, path HL7Path) (string, error) {
 	// split the message into segments by the segment separator which could be
 	// any of \r, \n, or \r\n.
 	segments := splitByAnyOf(message, []string{"\r\n", "\r", "\n"})
+	return extractFromSegments(segments, path, sep), nil
+}
+
+// extractFromSegments finds the value at path in the given segments. The
+// path must already be validated and not be the empty path.
+func extractFromSegments(segments []string, path HL7Path, sep Encoding) string {
+	fieldSeparator := sep.Field
+	repetitionSeparator := sep.Repetition
 
-	// loop over the segments and find the one that starts with the segment name
-	// in the path, if the segment index is greater than 1, we need to find the
-	// nth occurrence of the segment.
+	// loop over the segments and find the one named like the segment in the
+	// path, if the segment index is greater than 1, we need to find the nth
+	// occurrence of the segment. With a predicate only the segments holding
+	// its value count.
+	segmentIndex := path.SegmentIndex
+	if segmentIndex < 0 {
+		segmentIndex = resolveIndex(segmentIndex, countSelectedSegments(segments, path, sep))
+	}
 	segmentCount := 0
 	for _, segment := range segments {
-		if strings.HasPrefix(segment, path.Segment) {
+		if path.selectsSegment(segment, sep) {
 			segmentCount++
-			if segmentCount == path.SegmentIndex {
+			if segmentCount == segmentIndex {
 				// we found the target segment!
 				// if field is 0, we want the whole segment returned
 				if path.Field == 0 {
-					return segment, nil
+					return segment
 				}
 				// split the segment into fields by the field separator
 				fields := strings.Split(segment, string(fieldSeparator))
@@ -108,7 +83,7 @@ func AbstractHL7(message string, path HL7Path) (string, error) {
 				// if the field index is greater than the number of fields,
 				// return empty string
 				if path.Field >= len(fields) {
-					return "", nil
+					return ""
 				} else {
 					field := fields[path.Field]
 					// we found the target field!
@@ -121,58 +96,307 @@ func AbstractHL7(message string, path HL7Path) (string, error) {
 					} else {
 						repetitions = []string{field}
 					}
-					if path.RepetitionIndex > len(repetitions) {
-						return "", nil
+					repetitionIndex := resolveIndex(path.RepetitionIndex, len(repetitions))
+					if repetitionIndex < 1 || repetitionIndex > len(repetitions) {
+						return ""
 					} else {
-						repetition := repetitions[path.RepetitionIndex-1]
+						repetition := repetitions[repetitionIndex-1]
 						// we found the target repetition!
-						// if component is 0, we want the whole repetition
-						// returned
-						if path.Component == 0 {
-							return repetition, nil
-						}
-						// split by component...
-						components := strings.Split(repetition, string(componentSeparator))
-						if path.Component > len(components) {
-							return "", nil
-						} else {
-							component := components[path.Component-1]
-							// we found the target component!
-							if path.Subcomponent == 0 {
-								return component, nil
-							}
-							// split by subcomponent...
-							subcomponents := strings.Split(component, string(subcomponentSeparator))
-							if path.Subcomponent > len(subcomponents) {
-								return "", nil
-							} else {
-								return subcomponents[path.Subcomponent-1], nil
-							}
-						}
+						return extractFromRepetition(repetition, path, sep)
 					}
 				}
 			}
 		}
 	}
 
-	return "", nil
+	return ""
+}
+
+// isSegment reports whether segment is named name, that is it starts with
+// name followed by the field separator, or is nothing but the name. A plain
+// prefix match would let a name match the start of a longer one.
+func isSegment(segment string, name string, fieldSeparator byte) bool {
+	return strings.HasPrefix(segment, name) && (len(segment) == len(name) || segment[len(name)] == fieldSeparator)
+}
+
+// selectsSegment reports whether segment is one the path counts: named
+// p.Segment and, with a predicate, holding its value.
+func (p HL7Path) selectsSegment(segment string, sep Encoding) bool {
+	if !isSegment(segment, p.Segment, sep.Field) {
+		return false
+	}
+	return p.Where == (SegmentPredicate{}) || segmentValueView(segment, p.Where.path(p.Segment), sep) == p.Where.Value
+}
+
+// countSelectedSegments counts the segments extractFromSegments would match
+// for path, to resolve a negative segment index.
+func countSelectedSegments(segments []string, path HL7Path, sep Encoding) int {
+	count := 0
+	for _, segment := range segments {
+		if path.selectsSegment(segment, sep) {
+			count++
+		}
+	}
+	return count
+}
+
+// extractFromRepetition finds the component or subcomponent of path in a
+// single repetition of a field.
+func extractFromRepetition(repetition string, path HL7Path, sep Encoding) string {
+	// if component is 0, we want the whole repetition returned
+	if path.Component == 0 {
+		return repetition
+	}
+	// split by component...
+	components := splitEscaped(repetition, sep.Component, sep.Escape)
+	if path.Component > len(components) {
+		return ""
+	}
+	component := components[path.Component-1]
+	// we found the target component!
+	if path.Subcomponent == 0 {
+		return component
+	}
+	// split by subcomponent...
+	subcomponents := splitEscaped(component, sep.Subcomponent, sep.Escape)
+	if path.Subcomponent > len(subcomponents) {
+		return ""
+	}
+	return subcomponents[path.Subcomponent-1]
+}
+
+// AbstractHL7Opts works like AbstractHL7 but lets the caller opt into extra
+// extraction behavior, see Options for the defaults.
+func AbstractHL7Opts(message string, path HL7Path, opts ...Option) (string, error) {
+	o := buildOptions(opts)
+	// the empty path always returns the message as it was given
+	if o.CaseInsensitiveSegments && path != (HL7Path{}) {
+		message = upperSegmentNames(message)
+		path.Segment = strings.ToUpper(path.Segment)
+	}
+	if o.ExpectMessageType != "" {
+		if err := checkMessageType(message, o.ExpectMessageType); err != nil {
+			return "", err
+		}
+	}
+	if o.ADDContinuation && path != (HL7Path{}) {
+		message = stitchADDSegments(message)
+	}
+	value, err := AbstractHL7(message, path)
+	if err != nil {
+		return "", err
+	}
+	if o.Strict && path != (HL7Path{}) {
+		if err := checkInMessage(message, path); err != nil {
+			return "", err
+		}
+	}
+	isEncoding := path.Segment == "MSH" && (path.Field == 1 || path.Field == 2)
+	if path == (HL7Path{}) || isEncoding {
+		return value, nil
+	}
+	// the message was already validated by AbstractHL7
+	sep, _ := ParseEncoding(message)
+	if o.Truncate && sep.Truncation != 0 && allowsTruncation(message) {
+		value = truncateLeaves(value, path, sep)
+	}
+	if o.TrimTrailingEmpty {
+		value = trimTrailingEmpty(value, path, sep)
+	}
+	if o.TrimNulls && value == Null {
+		value = ""
+	}
+	if o.Unescape {
+		value = Unescape(value, sep)
+	}
+	if o.TrimCutset != "" {
+		value = strings.Trim(value, o.TrimCutset)
+	}
+	return value, nil
+}
+
+// upperSegmentNames returns message with every three character segment name
+// in upper case, the rest of the message is left as it is.
+func upperSegmentNames(message string) string {
+	if len(message) < 4 {
+		return message
+	}
+	fieldSeparator := message[3]
+	segments, terminators := splitSegmentsKeepingTerminators(message)
+	for i, segment := range segments {
+		end := strings.IndexByte(segment, fieldSeparator)
+		if end == -1 {
+			end = len(segment)
+		}
+		if end == 3 {
+			segments[i] = strings.ToUpper(segment[:3]) + segment[3:]
+		}
+	}
+	return joinSegments(segments, terminators)
+}
+
+// trimTrailingEmpty drops the separators at the end of value, and with them
+// the empty pieces they delimit. Only separators below the level of path can
+// be in value. The encoding characters in MSH-2 are left alone when value is
+// the MSH segment.
+func trimTrailingEmpty(value string, path HL7Path, sep Encoding) string {
+	cutset := separatorsBelow(path, sep)
+	if cutset == "" {
+		return value
+	}
+	keep, value, ok := cutEncodingCharacters(value, path, sep)
+	if !ok {
+		return value
+	}
+	return keep + strings.TrimRight(value, cutset)
+}
+
+// truncateLeaves cuts every leaf of value, the pieces between the separators
+// below the level of path, at the truncation character, dropping the marker
+// and the rest of that leaf only: a truncated PID-5.1 leaves PID-5.2 as it
+// was. The encoding characters in MSH-2 are left alone when value is the MSH
+// segment.
+func truncateLeaves(value string, path HL7Path, sep Encoding) string {
+	if strings.IndexByte(value, sep.Truncation) == -1 {
+		return value
+	}
+	separators := separatorsBelow(path, sep)
+	keep, value, ok := cutEncodingCharacters(value, path, sep)
+	if !ok {
+		return value
+	}
+	var b strings.Builder
+	b.WriteString(keep)
+	cut := false
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case strings.IndexByte(separators, c) != -1:
+			// a new leaf starts
+			cut = false
+			b.WriteByte(c)
+		case c == sep.Truncation:
+			cut = true
+		case !cut:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// separatorsBelow returns the separators a value at path can hold, those
+// below its level.
+func separatorsBelow(path HL7Path, sep Encoding) string {
+	switch {
+	case path.Field == 0:
+		return string([]byte{sep.Field, sep.Repetition, sep.Component, sep.Subcomponent})
+	case path.Component == 0:
+		return string([]byte{sep.Component, sep.Subcomponent})
+	case path.Subcomponent == 0:
+		return string(sep.Subcomponent)
+	default:
+		return ""
+	}
 }
 
+// cutEncodingCharacters splits an MSH segment value after MSH-2, so the
+// encoding characters can be kept as they are. Other values are returned
+// whole as rest. ok is false for an MSH segment without fields after MSH-2,
+// which has nothing to change.
+func cutEncodingCharacters(value string, path HL7Path, sep Encoding) (keep, rest string, ok bool) {
+	if path.Segment != "MSH" || path.Field != 0 {
+		return "", value, true
+	}
+	// MSH, MSH-1 and MSH-2
+	end := strings.IndexByte(value[4:], sep.Field)
+	if end == -1 {
+		return "", value, false
+	}
+	return value[:4+end], value[4+end:], true
+}
+
+// segmentLines splits a message into its segments on any of \r, \n or \r\n,
+// dropping blank lines.
+func segmentLines(message string) []string {
+	return strings.FieldsFunc(message, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	})
+}
+
+// splitSegmentsKeepingTerminators splits a message into segments like
+// splitByAnyOf, also returning the terminator (\r, \n, \r\n or "" for the
+// last segment) that followed each one so the message can be put back
+// together exactly as it was.
+func splitSegmentsKeepingTerminators(message string) ([]string, []string) {
+	var segments, terminators []string
+	for len(message) > 0 {
+		end := strings.IndexAny(message, "\r\n")
+		if end == -1 {
+			break
+		}
+		next := end + 1
+		if message[end] == '\r' && next < len(message) && message[next] == '\n' {
+			next++
+		}
+		segments = append(segments, message[:end])
+		terminators = append(terminators, message[end:next])
+		message = message[next:]
+	}
+	return append(segments, message), append(terminators, "")
+}
+
+// joinSegments is the reverse of splitSegmentsKeepingTerminators.
+func joinSegments(segments []string, terminators []string) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		b.WriteString(segment)
+		b.WriteString(terminators[i])
+	}
+	return b.String()
+}
+
+// splitByAnyOf splits s at every occurrence of any of the separators,
+// preferring the longest one where several match. The separators slice is
+// not modified.
 func splitByAnyOf(s string, separators []string) []string {
 	if len(separators) == 0 {
 		return []string{s}
 	}
-	slices.SortFunc(separators, func(a, b string) int {
+	// match the longest separators first so \r\n is split as one separator
+	// and not as \r followed by an empty segment and \n. Sort a copy, the
+	// caller's slice is theirs.
+	separators = slices.Clone(separators)
+	slices.SortStableFunc(separators, func(a, b string) int {
 		return len(b) - len(a)
 	})
-	for i, sep := range separators {
-		// if the first separator, skip for now because we will use it to split
-		// the message into segments later
-		if i == 0 {
-			continue
+	// only the first byte of a separator can start a match, so jump straight
+	// to those instead of trying every separator at every byte
+	var firstBytes strings.Builder
+	for _, sep := range separators {
+		if sep != "" {
+			firstBytes.WriteByte(sep[0])
 		}
-		s = strings.ReplaceAll(s, sep, separators[0])
 	}
-
-	return strings.Split(s, separators[0])
+	var res []string
+	start := 0
+	for i := 0; i < len(s); {
+		next := strings.IndexAny(s[i:], firstBytes.String())
+		if next == -1 {
+			break
+		}
+		i += next
+		matched := false
+		for _, sep := range separators {
+			if sep != "" && strings.HasPrefix(s[i:], sep) {
+				res = append(res, s[start:i])
+				i += len(sep)
+				start = i
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	return append(res, s[start:])
 }
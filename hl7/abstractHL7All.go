@@ -0,0 +1,74 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+var errWildcardPath = errors.New("wildcard paths can only be used with AbstractHL7All")
+
+// AbstractHL7All returns every value a wildcard path selects, in message
+// order. With a RepetitionIndex of WildcardRepetition (PID-3[*]) the
+// component or subcomponent of the path is read from each repetition of the
+// field. With a Component of WildcardComponent (PID-5.*) every component of
+// the repetition is returned, empty ones included so each keeps its position.
+// A Subcomponent of WildcardSubcomponent (PID-3.4.*) does the same with the
+// subcomponents of the component. A path without a wildcard returns the
+// single value AbstractHL7 would. A missing or empty field returns an empty
+// slice, not an error.
+func AbstractHL7All(message string, path HL7Path) ([]string, error) {
+	if path.Component == WildcardComponent || path.Subcomponent == WildcardSubcomponent {
+		if err := path.Validate(); err != nil {
+			return nil, err
+		}
+		// read the whole repetition or component and split it
+		whole := path
+		if path.Component == WildcardComponent {
+			whole.Component = 0
+		} else {
+			whole.Subcomponent = 0
+		}
+		value, err := AbstractHL7(message, whole)
+		if err != nil || value == "" {
+			return []string{}, err
+		}
+		// the message was already validated by AbstractHL7
+		sep, _ := ParseEncoding(message)
+		if path.Component == WildcardComponent {
+			return splitEscaped(value, sep.Component, sep.Escape), nil
+		}
+		return splitEscaped(value, sep.Subcomponent, sep.Escape), nil
+	}
+	if !path.hasWildcard() {
+		value, err := AbstractHL7(message, path)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			return []string{}, nil
+		}
+		return []string{value}, nil
+	}
+
+	field, err := GetFieldWithReps(message, path)
+	if err != nil {
+		return nil, err
+	}
+	if field == "" {
+		return []string{}, nil
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	// MSH-2 holds the encoding characters and is never split by repetition
+	repetitions := []string{field}
+	if !(path.Segment == "MSH" && path.Field == 2) {
+		repetitions = strings.Split(field, string(sep.Repetition))
+	}
+	values := make([]string, 0, len(repetitions))
+	for _, repetition := range repetitions {
+		values = append(values, extractFromRepetition(repetition, path, sep))
+	}
+	return values, nil
+}
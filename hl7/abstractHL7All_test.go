@@ -0,0 +1,116 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7All(t *testing.T) {
+	path, err1 := ParsePath("PID-3[*]")
+	resp, err2 := AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"555-44-4444^^^^SSN", "123^^^^MRN"}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-3[*].5")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"SSN", "MRN"}, resp, err1, err2)
+
+	// repetitions without the component keep their position
+	path, err1 = ParsePath("PID-5[*].3")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"E", ""}, resp, err1, err2)
+
+	path, err1 = ParsePath("ZZZ[1]-2[*].2.2")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"", "segment"}, resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-2[*]")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"^~\\&"}, resp, err1, err2)
+
+	// missing or empty fields
+	path, err1 = ParsePath("PID-4[*]")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-40[*]")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	path, err1 = ParsePath("EVN-1[*]")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	// no wildcard
+	path, err1 = ParsePath("PID-3[2].1")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"123"}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-4")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	_, err := AbstractHL7All("PID|1", path)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+
+	// the single value extractors refuse wildcards
+	path, err1 = ParsePath("PID-3[*]")
+	_, err = AbstractHL7(message, path)
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+	_, err = AbstractHL7View(message, path)
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+}
+
+func TestAbstractHL7AllComponents(t *testing.T) {
+	// empty components keep their position
+	path, err1 := ParsePath("PID-5.*")
+	resp, err2 := AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"EVERYWOMAN", "EVE", "E", "", "", "", "L"}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5[2].*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"QUE", "SUZY", "", "", "", "", "N"}, resp, err1, err2)
+
+	// subcomponents stay with their component
+	path, err1 = ParsePath("ZZZ-2[2].*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"a", "custom&segment&with", "custom&fields"}, resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[2]-5.*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"79"}, resp, err1, err2)
+
+	// missing or empty repetitions
+	path, err1 = ParsePath("PID-4.*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5[3].*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	_, err := AbstractHL7All(message, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 5, RepetitionIndex: 1, Component: WildcardComponent, Subcomponent: 1})
+	expectError(t, err, "if Component is a wildcard, Subcomponent must be empty or 0")
+
+	_, err = AbstractHL7(message, path)
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+}
+
+func TestAbstractHL7AllSubcomponents(t *testing.T) {
+	path, err1 := ParsePath("ZZZ-2[2].2.*")
+	resp, err2 := AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"custom", "segment", "with"}, resp, err1, err2)
+
+	// empty subcomponents keep their position
+	path, err1 = ParsePath("PID-3.4.*")
+	resp, err2 = AbstractHL7All("MSH|^~\\&|HIS\rPID|||123^^^&&HOSP&", path)
+	expectDeepValue(t, []string{"", "", "HOSP", ""}, resp, err1, err2)
+
+	// a component without subcomponents is a single one
+	path, err1 = ParsePath("PID-3[2].5.*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{"MRN"}, resp, err1, err2)
+
+	path, err1 = ParsePath("PID-3.2.*")
+	resp, err2 = AbstractHL7All(message, path)
+	expectDeepValue(t, []string{}, resp, err1, err2)
+
+	_, err := AbstractHL7(message, path)
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+}
@@ -0,0 +1,25 @@
+package hl7
+
+import "unsafe"
+
+// AbstractHL7Bytes is AbstractHL7 for a message held in a byte slice, without
+// converting it to a string: the message is read in place with
+// AbstractHL7View and the result is a subslice of message, sharing its
+// memory. It is nil when the value is empty.
+//
+// message must not be modified while AbstractHL7Bytes runs, and modifying
+// the result modifies message. Copy the result with bytes.Clone if it needs
+// to outlive the message or be changed.
+func AbstractHL7Bytes(message []byte, path HL7Path) ([]byte, error) {
+	if len(message) == 0 {
+		_, err := AbstractHL7View("", path)
+		return nil, err
+	}
+	view, err := AbstractHL7View(unsafe.String(&message[0], len(message)), path)
+	if err != nil || view == "" {
+		return nil, err
+	}
+	// view is a substring of message, find where it starts
+	offset := int(uintptr(unsafe.Pointer(unsafe.StringData(view))) - uintptr(unsafe.Pointer(&message[0])))
+	return message[offset : offset+len(view) : offset+len(view)], nil
+}
@@ -0,0 +1,42 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7Bytes(t *testing.T) {
+	data := []byte(message)
+	for _, p := range []string{
+		"", "MSH", "MSH-1", "MSH-2", "MSH-9.2", "PID-3[2].5", "PID-5[2].2", "PID-19",
+		"OBX[-1]-5", "OBX[3]", "ZZZ-2[2].2.2", "ZZZ[2].4",
+	} {
+		path, err1 := ParsePath(p)
+		expected, err2 := AbstractHL7(message, path)
+		resp, err3 := AbstractHL7Bytes(data, path)
+		expectValue(t, expected, string(resp), err1, err2, err3)
+	}
+
+	// the result is a part of the message, not a copy
+	resp, err := AbstractHL7Bytes(data, MustParsePath("PID-5.1"))
+	expectValue(t, "EVERYWOMAN", string(resp), err)
+	resp[0] = 'e'
+	expectValue(t, "eVERYWOMAN", string(data[len("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||555-44-4444^^^^SSN~123^^^^MRN||"):][:10]))
+	// and can't grow into the rest of it
+	expectValue(t, 10, cap(resp))
+
+	_, err = AbstractHL7Bytes([]byte("PID|1"), MustParsePath("PID-1"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	_, err = AbstractHL7Bytes(nil, MustParsePath("PID-1"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	resp, err = AbstractHL7Bytes(nil, HL7Path{})
+	expectValue(t, 0, len(resp), err)
+	_, err = AbstractHL7Bytes(data, HL7Path{Segment: "MSH", SegmentIndex: 2})
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+}
+
+func TestAbstractHL7BytesAllocs(t *testing.T) {
+	data := []byte(message)
+	path := MustParsePath("PID-3[2].5")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = AbstractHL7Bytes(data, path)
+	})
+	expectValue(t, 0.0, allocs)
+}
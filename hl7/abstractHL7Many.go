@@ -0,0 +1,36 @@
+package hl7
+
+// AbstractHL7Many returns the values at paths, in the same order, reading the
+// separators and splitting the message into segments only once. A path that
+// doesn't resolve to anything gives an empty value, like it does with
+// AbstractHL7. An invalid or wildcard path, or a message without a valid
+// header, returns an error and no values.
+func AbstractHL7Many(message string, paths []HL7Path) ([]string, error) {
+	for _, path := range paths {
+		if err := path.Validate(); err != nil {
+			return nil, &ExtractError{Path: path, Stage: StagePath, Err: err}
+		}
+		if path.hasWildcard() {
+			return nil, &ExtractError{Path: path, Stage: StagePath, Err: errWildcardPath}
+		}
+	}
+
+	// the message is only validated if a path needs more than the message
+	// itself, like AbstractHL7
+	var e *Extractor
+	values := make([]string, len(paths))
+	for i, path := range paths {
+		if path == (HL7Path{}) {
+			values[i] = message
+			continue
+		}
+		if e == nil {
+			var err error
+			if e, err = NewExtractor(message); err != nil {
+				return nil, &ExtractError{Path: path, Stage: StageHeader, Err: err}
+			}
+		}
+		values[i], _ = e.Get(path)
+	}
+	return values, nil
+}
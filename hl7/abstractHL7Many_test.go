@@ -0,0 +1,32 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7Many(t *testing.T) {
+	var paths []HL7Path
+	for _, p := range []string{"MSH-1", "MSH-2", "MSH-9.2", "PID-3[2].1", "PID-5[-1].2", "OBX[2]-5", "PID-40", "NTE-1", "ZZZ[2]", ""} {
+		path, err := ParsePath(p)
+		expectValue(t, nil, err)
+		paths = append(paths, path)
+	}
+	values, err := AbstractHL7Many(message, paths)
+	expectValue(t, nil, err)
+	// every value is the one AbstractHL7 returns
+	for i, path := range paths {
+		expected, err := AbstractHL7(message, path)
+		expectValue(t, expected, values[i], err)
+	}
+	expectDeepValue(t, []string{"|", "^~\\&", "A01", "123", "SUZY", "79", "", "", "ZZZ||foo|bar|baz", message}, values)
+
+	values, err = AbstractHL7Many(message, nil)
+	expectDeepValue(t, []string{}, values, err)
+
+	_, err = AbstractHL7Many(message, []HL7Path{paths[0], {Segment: "PID", SegmentIndex: 1, Component: 1}})
+	expectError(t, err, "if Component is set, Field must be set")
+
+	_, err = AbstractHL7Many(message, []HL7Path{{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: WildcardRepetition}})
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+
+	_, err = AbstractHL7Many("PID|1", paths)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,149 @@
+package hl7
+
+import "strings"
+
+// AbstractHL7View returns the same value as AbstractHL7 without allocating:
+// the result is always a substring of message and shares its backing memory.
+//
+// Because of that, holding on to the result keeps the whole message in
+// memory, and callers that build message from a []byte without copying (e.g.
+// with unsafe.String) must not mutate those bytes while the result is in use.
+// Copy the result with strings.Clone if it needs to outlive the message.
+func AbstractHL7View(message string, path HL7Path) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: err}
+	}
+	if path.hasWildcard() {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: errWildcardPath}
+	}
+	if path == (HL7Path{}) {
+		return message, nil
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", &ExtractError{Path: path, Stage: StageHeader, Err: err}
+	}
+	if path.Segment == "MSH" && path.Field == 1 {
+		return message[3:4], nil
+	}
+
+	segment, ok := nthSegmentView(message, path, sep)
+	if !ok {
+		return "", nil
+	}
+	return segmentValueView(segment, path, sep), nil
+}
+
+// segmentValueView returns the value at path in segment, the one path
+// references, as a substring of it.
+func segmentValueView(segment string, path HL7Path, sep Encoding) string {
+	if path.Field == 0 {
+		return segment
+	}
+	if path.Segment == "MSH" && path.Field == 1 {
+		return segment[3:4]
+	}
+	// MSH-1 is the separator between MSH and MSH-2, so every MSH field is one
+	// piece earlier than its number
+	fieldIndex := path.Field
+	if path.Segment == "MSH" {
+		fieldIndex--
+	}
+	field, ok := nthPiece(segment, sep.Field, fieldIndex)
+	if !ok {
+		return ""
+	}
+	repetition := field
+	if !(path.Segment == "MSH" && path.Field == 2) {
+		repetitionIndex := resolveIndex(path.RepetitionIndex, strings.Count(field, string(sep.Repetition))+1)
+		if repetitionIndex < 1 {
+			return ""
+		}
+		if repetition, ok = nthPiece(field, sep.Repetition, repetitionIndex-1); !ok {
+			return ""
+		}
+	} else if resolveIndex(path.RepetitionIndex, 1) != 1 {
+		return ""
+	}
+	if path.Component == 0 {
+		return repetition
+	}
+	// separators in escape sequences are data from here on, like AbstractHL7
+	component, ok := nthPieceUnescaped(repetition, sep.Component, sep.Escape, path.Component-1)
+	if !ok || path.Subcomponent == 0 {
+		return component
+	}
+	subcomponent, _ := nthPieceUnescaped(component, sep.Subcomponent, sep.Escape, path.Subcomponent-1)
+	return subcomponent
+}
+
+// nthSegmentView finds the segment path references, the SegmentIndex-th
+// (1-based, or counted from the end when negative) one it selects, without
+// splitting the message.
+func nthSegmentView(message string, path HL7Path, sep Encoding) (string, bool) {
+	n := path.SegmentIndex
+	if n < 0 {
+		total := 0
+		for rest := message; len(rest) > 0; {
+			line := rest
+			if end := strings.IndexAny(rest, "\r\n"); end != -1 {
+				line, rest = rest[:end], rest[end+1:]
+			} else {
+				rest = ""
+			}
+			if path.selectsSegment(line, sep) {
+				total++
+			}
+		}
+		n = resolveIndex(n, total)
+	}
+	count := 0
+	for len(message) > 0 {
+		end := strings.IndexAny(message, "\r\n")
+		line := message
+		if end != -1 {
+			line, message = message[:end], message[end+1:]
+		} else {
+			message = ""
+		}
+		if path.selectsSegment(line, sep) {
+			count++
+			if count == n {
+				return line, true
+			}
+		}
+	}
+	return "", false
+}
+
+// nthPiece returns the nth (0-based) piece of s split by sep without
+// splitting the whole string.
+func nthPiece(s string, sep byte, n int) (string, bool) {
+	for ; n > 0; n-- {
+		i := strings.IndexByte(s, sep)
+		if i == -1 {
+			return "", false
+		}
+		s = s[i+1:]
+	}
+	if i := strings.IndexByte(s, sep); i != -1 {
+		s = s[:i]
+	}
+	return s, true
+}
+
+// nthPieceUnescaped is nthPiece for pieces that can have escape sequences
+// with separators in them, see splitEscaped.
+func nthPieceUnescaped(s string, sep byte, escape byte, n int) (string, bool) {
+	for ; n > 0; n-- {
+		i := indexUnescaped(s, sep, escape)
+		if i == -1 {
+			return "", false
+		}
+		s = s[i+1:]
+	}
+	if i := indexUnescaped(s, sep, escape); i != -1 {
+		s = s[:i]
+	}
+	return s, true
+}
@@ -0,0 +1,39 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7View(t *testing.T) {
+	for _, p := range []string{
+		"", "MSH", "MSH.1", "MSH.2", "MSH.2[2]", "MSH.3", "MSH.9.2", "MSH.12", "MSH.40",
+		"PID", "PID.3", "PID.3[2]", "PID-3.5", "PID-3[2].5", "PID-3[3]", "PID-5[2].2",
+		"PID-11.3", "PID-19", "PID-5.1.2", "OBX[2].5", "OBX[3].1", "ZZZ-2[2].2.2",
+		"ZZZ-2[2].2.9", "ZZZ[2]-5", "PID-3[-1].5", "PID-3[-3]", "MSH-2[-1]", "OBX[-1]-5",
+		"OBX[-2]", "OBX[-3]", "ZZZ[-1]-2[-1]",
+	} {
+		path, err1 := ParsePath(p)
+		expected, err2 := AbstractHL7(message, path)
+		resp, err3 := AbstractHL7View(message, path)
+		expectValue(t, expected, resp, err1, err2, err3)
+	}
+
+	_, err := AbstractHL7View("PID|1", HL7Path{Segment: "PID", SegmentIndex: 1})
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+
+	_, err = AbstractHL7View(message, HL7Path{Segment: "MSH", SegmentIndex: 2})
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+}
+
+func TestAbstractHL7ViewAllocs(t *testing.T) {
+	path := HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: 2, Component: 5}
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = AbstractHL7View(message, path)
+	})
+	expectValue(t, 0.0, allocs)
+
+	// matching a segment predicate doesn't allocate either
+	path = MustParsePath("OBX{3.2=Body Weight}-5")
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _ = AbstractHL7View(message, path)
+	})
+	expectValue(t, 0.0, allocs)
+}
@@ -90,3 +90,281 @@ func TestAbstractHL7(t *testing.T) {
 	expectValue(t, "segment", resp, err1, err2)
 
 }
+
+func TestAbstractHL7NegativeIndex(t *testing.T) {
+	for p, expected := range map[string]string{
+		"PID-3[-1].5": "MRN",
+		"PID-3[-2].5": "SSN",
+		"PID-3[-3].5": "",
+		"PV1-2[-1]":   "I",
+		"OBX[-1]-5":   "79",
+		"OBX[-2]-5":   "1.80",
+		"OBX[-3]-5":   "",
+		"ZZZ[-1]-4":   "baz",
+	} {
+		path, err1 := ParsePath(p)
+		resp, err2 := AbstractHL7(message, path)
+		expectValue(t, expected, resp, err1, err2)
+	}
+}
+
+func TestAbstractHL7Predicate(t *testing.T) {
+	for p, expected := range map[string]string{
+		"OBX{3.2=Body Weight}-5":      "79",
+		"OBX{3.2=Body Height}-5":      "1.80",
+		"OBX{3=^Body Weight}-6":       "kg",
+		"OBX{3.1=}-5":                 "1.80",
+		"OBX{3.1=}[2]-5":              "79",
+		"OBX{3.1=}[-1]-5":             "79",
+		"OBX{3.1=}[3]-5":              "",
+		"OBX{3.2=Body Temp}-5":        "",
+		"OBX{3.2=body weight}-5":      "",
+		"OBX{11=F}[-2]-1":             "1",
+		"PID{3=555-44-4444^^^^SSN}-8": "F",
+		"PID{3=123^^^^MRN}-8":         "",
+		"PID{8=F}-7":                  "19610615",
+		"ZZZ{2=This is}-2[2].1":       "a",
+		"ZZZ{2.2.2=segment}-2[2].1":   "",
+		"ZZZ{3=bar}-4":                "baz",
+		"MSH{9.2=A01}-10":             "MSG00001",
+		"MSH{1=|}-3":                  "HIS",
+		"MSH{9.2=A08}-10":             "",
+	} {
+		path, err1 := ParsePath(p)
+		resp, err2 := AbstractHL7(message, path)
+		expectValue(t, expected, resp, err1, err2)
+
+		// every way of reading the message agrees
+		resp, err2 = AbstractHL7View(message, path)
+		expectValue(t, expected, resp, err1, err2)
+		m, err := Parse(message)
+		resp, err2 = m.Get(path)
+		expectValue(t, expected, resp, err, err2)
+	}
+
+	// the predicate is compared with the first repetition
+	path := MustParsePath("PID{3.5=MRN}-7")
+	resp, err := AbstractHL7(message, path)
+	expectValue(t, "", resp, err)
+	path = MustParsePath("PID{3.5=SSN}-7")
+	resp, err = AbstractHL7(message, path)
+	expectValue(t, "19610615", resp, err)
+}
+
+func TestAbstractHL7SegmentNameBoundary(t *testing.T) {
+	// PIDX and OBXX start with the name of a segment but are not that segment
+	crafted := "MSH|^~\\&|HIS\rPIDX|wrong|wrong\rPID|right|right\rOBXX|wrong\rOBX\rOBX|2"
+	for p, expected := range map[string]string{
+		"PID-1":     "right",
+		"PID[2]-1":  "",
+		"PID[-1]-2": "right",
+		"OBX":       "OBX",
+		"OBX[2]-1":  "2",
+		"OBX[-1]-1": "2",
+		"OBX[3]":    "",
+	} {
+		path, err1 := ParsePath(p)
+		resp, err2 := AbstractHL7(crafted, path)
+		expectValue(t, expected, resp, err1, err2)
+		resp, err2 = AbstractHL7View(crafted, path)
+		expectValue(t, expected, resp, err1, err2)
+	}
+
+	path, err1 := ParsePath("PID-1")
+	_, metrics, err2 := AbstractHL7Metrics(crafted, path)
+	expectValue(t, 3, metrics.SegmentsScanned, err1, err2)
+
+	resp, err2 := SetHL7(crafted, path, "set")
+	expectValue(t, "MSH|^~\\&|HIS\rPIDX|wrong|wrong\rPID|set|right\rOBXX|wrong\rOBX\rOBX|2", resp, err1, err2)
+}
+
+func TestAbstractHL7MixedLineEndings(t *testing.T) {
+	mixed := "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\r\nPID|||123^^^^MRN||DOE^JANE\nPV1||I\rOBX|1|ST|^Body Height||1.80\r\nOBX|2|ST|^Body Weight||79\n"
+
+	path, err1 := ParsePath("MSH-12")
+	resp, err2 := AbstractHL7(mixed, path)
+	expectValue(t, "2.5", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5.2")
+	resp, err2 = AbstractHL7(mixed, path)
+	expectValue(t, "JANE", resp, err1, err2)
+
+	path, err1 = ParsePath("PV1-2")
+	resp, err2 = AbstractHL7(mixed, path)
+	expectValue(t, "I", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[1]-5")
+	resp, err2 = AbstractHL7(mixed, path)
+	expectValue(t, "1.80", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[2]-5")
+	resp, err2 = AbstractHL7(mixed, path)
+	expectValue(t, "79", resp, err1, err2)
+
+	path, err1 = ParsePath("PV1")
+	resp, err2 = AbstractHL7(mixed, path)
+	expectValue(t, "PV1||I", resp, err1, err2)
+}
+
+func TestSplitByAnyOf(t *testing.T) {
+	expectDeepValue(t,
+		[]string{"a", "b", "c", "d", "", "e", ""},
+		splitByAnyOf("a\r\nb\rc\nd\r\n\re\n", []string{"\r\n", "\r", "\n"}),
+	)
+	expectDeepValue(t, []string{"abc"}, splitByAnyOf("abc", nil))
+
+	// the separators are used as given, never rewritten to one another
+	expectDeepValue(t, []string{"a", "b\rc", "d"}, splitByAnyOf("a\nb\rc\nd", []string{"\n"}))
+}
+
+func TestSplitByAnyOfLeavesSeparatorsUnchanged(t *testing.T) {
+	separators := []string{"\n", "\r", "\r\n"}
+	expectDeepValue(t, []string{"a", "b", "c"}, splitByAnyOf("a\r\nb\nc", separators))
+	expectDeepValue(t, []string{"\n", "\r", "\r\n"}, separators)
+}
+
+func TestAbstractHL7OptsTrimCutset(t *testing.T) {
+	quoted := "MSH|^~\\&|\"HIS\"|\"RIH\"|||20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||\"123\"^^^^\"MRN\"||\"EVERYWOMAN\"^ EVE "
+
+	path, err1 := ParsePath("PID-3.1")
+	resp, err2 := AbstractHL7Opts(quoted, path)
+	expectValue(t, "\"123\"", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(quoted, path, WithTrimCutset(`"`))
+	expectValue(t, "123", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5.2")
+	resp, err2 = AbstractHL7Opts(quoted, path, WithTrimCutset(`" `))
+	expectValue(t, "EVE", resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-3")
+	resp, err2 = AbstractHL7Opts(quoted, path, WithTrimCutset(`"`))
+	expectValue(t, "HIS", resp, err1, err2)
+
+	// the encoding characters are left alone
+	path, err1 = ParsePath("MSH-2")
+	resp, err2 = AbstractHL7Opts(quoted, path, WithTrimCutset(`^&`))
+	expectValue(t, "^~\\&", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(quoted, HL7Path{}, WithTrimCutset(`M`))
+	expectValue(t, quoted, resp, err2)
+}
+
+func TestAbstractHL7RepetitionsAreIndependent(t *testing.T) {
+	// PID-3 repetition 1 has fewer components than repetition 2, PID-5
+	// repetition 1 has more components than repetition 2
+	uneven := "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||123~456^^^^MRN^HOSP~789^X||DOE^JANE^Q^JR^DR^^L~SMITH^J|F"
+
+	for _, c := range []struct{ path, expected string }{
+		{"PID-3.1", "123"},
+		{"PID-3.5", ""},
+		{"PID-3.6", ""},
+		{"PID-3[2].5", "MRN"},
+		{"PID-3[2].6", "HOSP"},
+		{"PID-3[2].7", ""},
+		{"PID-3[3].2", "X"},
+		{"PID-3[3].5", ""},
+		{"PID-5.7", "L"},
+		{"PID-5[2].1", "SMITH"},
+		{"PID-5[2].2", "J"},
+		{"PID-5[2].3", ""},
+		{"PID-5[2].7", ""},
+		{"PID-5[2].2.1", "J"},
+		{"PID-5[2].2.2", ""},
+	} {
+		path, err1 := ParsePath(c.path)
+		resp, err2 := AbstractHL7(uneven, path)
+		expectValue(t, c.expected, resp, err1, err2)
+		resp, err2 = AbstractHL7View(uneven, path)
+		expectValue(t, c.expected, resp, err1, err2)
+	}
+}
+
+func TestAbstractHL7EmptyPathPassthrough(t *testing.T) {
+	for _, m := range []string{
+		message,
+		// escapes, mixed and repeated terminators, trailing whitespace
+		"MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\r\n" +
+			"PID|||123^^^^MRN||DOE\\S\\SMITH^JANE\\T\\JO\\X0D0A\\\n\n" +
+			"NTE|1||line one\\.br\\line two \\H\\bold\\N\\ \r\r" +
+			"ZZZ||\"\"|  \r\n",
+		// not even valid HL7
+		"PID|1\r",
+		"",
+	} {
+		path, err1 := ParsePath("")
+		resp, err2 := AbstractHL7(m, path)
+		expectValue(t, m, resp, err1, err2)
+
+		resp, err2 = AbstractHL7View(m, path)
+		expectValue(t, m, resp, err2)
+
+		resp, err2 = AbstractHL7Opts(m, path, WithADDContinuation(), WithTrimCutset(" \r\n"))
+		expectValue(t, m, resp, err2)
+	}
+}
+
+func TestAbstractHL7EscapedSubcomponentSeparator(t *testing.T) {
+	// \T\ is an escaped & and is data, never a subcomponent boundary
+	msg := "MSH|^~\\&|HIS\rZZZ|1|SMITH \\T\\ SONS&INC^B\\T\\\\T\\&C~D\rZZZ|2|\\T\\"
+
+	path, err1 := ParsePath("ZZZ-2.1")
+	resp, err2 := AbstractHL7(msg, path)
+	expectValue(t, "SMITH \\T\\ SONS&INC", resp, err1, err2)
+	path, err1 = ParsePath("ZZZ-2.1.1")
+	resp, err2 = AbstractHL7(msg, path)
+	expectValue(t, "SMITH \\T\\ SONS", resp, err1, err2)
+	path, err1 = ParsePath("ZZZ-2.1.2")
+	resp, err2 = AbstractHL7(msg, path)
+	expectValue(t, "INC", resp, err1, err2)
+	path, err1 = ParsePath("ZZZ-2.2.1")
+	resp, err2 = AbstractHL7(msg, path)
+	expectValue(t, "B\\T\\\\T\\", resp, err1, err2)
+	path, err1 = ParsePath("ZZZ[2]-2.1.2")
+	resp, err2 = AbstractHL7(msg, path)
+	expectValue(t, "", resp, err1, err2)
+
+	values, err := AbstractHL7All(msg, MustParsePath("ZZZ-2.2.*"))
+	expectDeepValue(t, []string{"B\\T\\\\T\\", "C"}, values, err)
+	m, err1 := Parse(msg)
+	resp, err2 = m.Get(MustParsePath("ZZZ-2.1.1"))
+	expectValue(t, "SMITH \\T\\ SONS", resp, err1, err2)
+
+	// it is only an & when unescaping is asked for
+	resp, err = AbstractHL7Opts(msg, MustParsePath("ZZZ-2.1.1"), WithUnescape())
+	expectValue(t, "SMITH & SONS", resp, err)
+	resp, err = AbstractHL7Opts(msg, MustParsePath("ZZZ-2.2.1"), WithUnescape())
+	expectValue(t, "B&&", resp, err)
+	resp, err = AbstractHL7Opts(msg, MustParsePath("ZZZ[2]-2.1.1"), WithUnescape())
+	expectValue(t, "&", resp, err)
+}
+
+func TestAbstractHL7EscapedSeparators(t *testing.T) {
+	// \S\ is an escaped ^, and a locally defined escape sequence can have
+	// separators in it, neither splits a component or subcomponent
+	msg := "MSH|^~\\&|HIS\rZZZ|1|A\\S\\B^C\\Zx^y&z\\D&E^F"
+
+	for p, expected := range map[string]string{
+		"ZZZ-2.1":   "A\\S\\B",
+		"ZZZ-2.2":   "C\\Zx^y&z\\D&E",
+		"ZZZ-2.2.1": "C\\Zx^y&z\\D",
+		"ZZZ-2.2.2": "E",
+		"ZZZ-2.3":   "F",
+		"ZZZ-2.4":   "",
+	} {
+		path := MustParsePath(p)
+		resp, err := AbstractHL7(msg, path)
+		expectValue(t, expected, resp, err)
+		resp, err = AbstractHL7View(msg, path)
+		expectValue(t, expected, resp, err)
+		m, err1 := Parse(msg)
+		resp, err2 := m.Get(path)
+		expectValue(t, expected, resp, err1, err2)
+	}
+	values, err := AbstractHL7All(msg, MustParsePath("ZZZ-2.*"))
+	expectDeepValue(t, []string{"A\\S\\B", "C\\Zx^y&z\\D&E", "F"}, values, err)
+	resp, err := AbstractHL7Opts(msg, MustParsePath("ZZZ-2.1"), WithUnescape())
+	expectValue(t, "A^B", resp, err)
+	count, err := LeafCount(msg)
+	expectValue(t, 8, count, err)
+}
@@ -0,0 +1,64 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// now is the clock GenerateACK stamps acknowledgments with.
+var now = time.Now
+
+// ackCodes are the acknowledgment codes of HL7 table 0008, original mode
+// (AA, AE, AR) and enhanced mode (CA, CE, CR).
+var ackCodes = map[string]bool{
+	"AA": true, "AE": true, "AR": true, "CA": true, "CE": true, "CR": true,
+}
+
+// GenerateACK builds the acknowledgment for message with the given code, e.g.
+// AA for application accept. The ACK uses the encoding characters of message,
+// swaps its sending and receiving application and facility, echoes its
+// processing ID and version, and its MSA-2 is the control ID (MSH-10) of
+// message. The ACK gets a control ID and timestamp of its own, and every
+// segment is terminated with \r.
+func GenerateACK(message string, code string) (string, error) {
+	if !ackCodes[code] {
+		return "", fmt.Errorf("invalid acknowledgment code %q, expected one of AA, AE, AR, CA, CE, CR", code)
+	}
+	enc, err := ValidateMSH(message)
+	if err != nil {
+		return "", err
+	}
+	// fields[n-1] is MSH-n, fields[1] is MSH-2 as MSH-1 is the separator
+	// between the segment name and MSH-2
+	msh, _, _ := strings.Cut(message, "\r")
+	msh, _, _ = strings.Cut(msh, "\n")
+	fields := strings.Split(msh, string(enc.Field))
+	field := func(n int) string {
+		if n-1 < len(fields) {
+			return fields[n-1]
+		}
+		return ""
+	}
+	controlID := field(10)
+	if controlID == "" {
+		return "", errors.New("message has no control ID (MSH-10) to acknowledge")
+	}
+
+	messageType := "ACK"
+	if _, trigger, ok := strings.Cut(field(9), string(enc.Component)); ok {
+		trigger, _, _ = strings.Cut(trigger, string(enc.Component))
+		if trigger != "" {
+			messageType = strings.Join([]string{"ACK", trigger, "ACK"}, string(enc.Component))
+		}
+	}
+	t := now()
+	msh = strings.Join([]string{
+		"MSH", field(2), field(5), field(6), field(3), field(4),
+		t.Format("20060102150405"), "", messageType, strings.Replace(t.Format("ACK20060102150405.000000"), ".", "", 1),
+		field(11), field(12),
+	}, string(enc.Field))
+	msa := strings.Join([]string{"MSA", code, controlID}, string(enc.Field))
+	return msh + "\r" + msa + "\r", nil
+}
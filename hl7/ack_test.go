@@ -0,0 +1,36 @@
+package hl7
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateACK(t *testing.T) {
+	defer func(clock func() time.Time) { now = clock }(now)
+	now = func() time.Time {
+		return time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	}
+
+	ack, err := GenerateACK(message, "AA")
+	expectValue(t, "MSH|^~\\&|EKG|EKG|HIS|RIH|20240102030405||ACK^A01^ACK|ACK20240102030405000006|P|2.5\rMSA|AA|MSG00001\r", ack, err)
+
+	// the ACK can be read like any other message
+	path, _ := ParsePath("MSA-2")
+	id, err := AbstractHL7(ack, path)
+	expectValue(t, "MSG00001", id, err)
+
+	// custom separators are kept and the trigger is optional
+	ack, err = GenerateACK("MSH#!@$%#APP!1#FAC#OTHER#DEST#20240101##ORU#42#T#2.3\nPID#1", "AE")
+	expectValue(t, "MSH#!@$%#OTHER#DEST#APP!1#FAC#20240102030405##ACK#ACK20240102030405000006#T#2.3\rMSA#AE#42\r", ack, err)
+}
+
+func TestGenerateACKErrors(t *testing.T) {
+	_, err := GenerateACK(message, "OK")
+	expectError(t, err, `invalid acknowledgment code "OK", expected one of AA, AE, AR, CA, CE, CR`)
+
+	_, err = GenerateACK("PID|1", "AA")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+
+	_, err = GenerateACK("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01||P|2.5", "AA")
+	expectError(t, err, "message has no control ID (MSH-10) to acknowledge")
+}
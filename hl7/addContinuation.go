@@ -0,0 +1,32 @@
+package hl7
+
+import "strings"
+
+// stitchADDSegments appends the content of every ADD segment onto the segment
+// before it. Legacy senders that hit a segment length limit spill the rest of
+// the segment into one or more ADD segments, e.g.
+//
+//	OBX|1|TX|^Note||This is a very long
+//	ADD| note that was continued
+//
+// becomes a single OBX segment. If the overflowed segment ended on a field
+// separator the ADD content begins a new field, otherwise it continues the
+// last field. Blank lines are dropped and the segments are rejoined with \r.
+func stitchADDSegments(message string) string {
+	if len(message) < 4 {
+		return message
+	}
+	fieldSeparator := message[3]
+	segments := segmentLines(message)
+	stitched := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if len(stitched) > 0 && (segment == "ADD" || strings.HasPrefix(segment, "ADD"+string(fieldSeparator))) {
+			if len(segment) > 4 {
+				stitched[len(stitched)-1] += segment[4:]
+			}
+			continue
+		}
+		stitched = append(stitched, segment)
+	}
+	return strings.Join(stitched, "\r")
+}
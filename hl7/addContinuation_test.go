@@ -0,0 +1,36 @@
+package hl7
+
+import "testing"
+
+var continuedMessage = "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.3\rOBX|1|TX|^Note||This is a very long\rADD| note that was continued\rADD||F|\rOBX|2|ST|^Body Weight||79|kg"
+
+func TestStitchADDSegments(t *testing.T) {
+	expectValue(t,
+		"MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.3\rOBX|1|TX|^Note||This is a very long note that was continued|F|\rOBX|2|ST|^Body Weight||79|kg",
+		stitchADDSegments(continuedMessage),
+	)
+}
+
+func TestAbstractHL7OptsADDContinuation(t *testing.T) {
+	path, err1 := ParsePath("OBX.6")
+
+	// by default the ADD segment is left alone
+	resp, err2 := AbstractHL7Opts(continuedMessage, path)
+	expectValue(t, "", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX.5")
+	resp, err2 = AbstractHL7Opts(continuedMessage, path, WithADDContinuation())
+	expectValue(t, "This is a very long note that was continued", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX.6")
+	resp, err2 = AbstractHL7Opts(continuedMessage, path, WithADDContinuation())
+	expectValue(t, "F", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[2].5")
+	resp, err2 = AbstractHL7Opts(continuedMessage, path, WithADDContinuation())
+	expectValue(t, "79", resp, err1, err2)
+
+	// the whole message is never rewritten
+	resp, err2 = AbstractHL7Opts(continuedMessage, HL7Path{}, WithADDContinuation())
+	expectValue(t, continuedMessage, resp, err2)
+}
@@ -0,0 +1,61 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	aliasesMu sync.RWMutex
+	aliases   = map[string]HL7Path{}
+)
+
+// RegisterAlias registers a business friendly name for a path, e.g.
+// RegisterAlias("MRN", "PID-3.1"), so it can be extracted with ExtractAlias.
+// Registering an existing name replaces it. See ExtractAlias for how names
+// that are also valid paths are resolved.
+func RegisterAlias(name, pathStr string) error {
+	if name == "" {
+		return errors.New("alias name must not be empty")
+	}
+	path, err := ParsePath(pathStr)
+	if err != nil {
+		return fmt.Errorf("alias %q: %w", name, err)
+	}
+	if err := path.Validate(); err != nil {
+		return fmt.Errorf("alias %q: %w", name, err)
+	}
+	aliasesMu.Lock()
+	defer aliasesMu.Unlock()
+	aliases[name] = path
+	return nil
+}
+
+// ExtractAlias extracts the value at the path registered under name.
+//
+// When name is also a valid path the structural interpretation wins so a
+// registered alias can never shadow it: a path with a field number (e.g.
+// "PID-5") is always read as that path, and a bare segment name (e.g. "OBX")
+// is read as the segment whenever the message contains it. Only a bare
+// segment name that is missing from the message falls back to the alias.
+func ExtractAlias(message string, name string) (string, error) {
+	path, pathErr := ParsePath(name)
+	if name != "" && pathErr == nil {
+		value, err := AbstractHL7(message, path)
+		if err != nil || path.Field != 0 || value != "" {
+			return value, err
+		}
+	}
+	aliasesMu.RLock()
+	path, ok := aliases[name]
+	aliasesMu.RUnlock()
+	if !ok {
+		if name != "" && pathErr == nil {
+			// a segment that just isn't in this message
+			return "", nil
+		}
+		return "", fmt.Errorf("unknown alias %q", name)
+	}
+	return AbstractHL7(message, path)
+}
@@ -0,0 +1,69 @@
+package hl7
+
+import "testing"
+
+// registerAlias registers an alias for the duration of the test.
+func registerAlias(t *testing.T, name, pathStr string) error {
+	t.Helper()
+	t.Cleanup(func() {
+		aliasesMu.Lock()
+		defer aliasesMu.Unlock()
+		delete(aliases, name)
+	})
+	return RegisterAlias(name, pathStr)
+}
+
+func TestExtractAlias(t *testing.T) {
+	err1 := registerAlias(t, "MRN", "PID-3[2].1")
+	resp, err2 := ExtractAlias(message, "MRN")
+	expectValue(t, "123", resp, err1, err2)
+
+	// re-registering replaces the path
+	err1 = registerAlias(t, "MRN", "PID-3.1")
+	resp, err2 = ExtractAlias(message, "MRN")
+	expectValue(t, "555-44-4444", resp, err1, err2)
+
+	_, err := ExtractAlias(message, "Nope")
+	expectError(t, err, `unknown alias "Nope"`)
+
+	err = registerAlias(t, "", "PID-3")
+	expectError(t, err, "alias name must not be empty")
+
+	err = registerAlias(t, "Bad", "PID-")
+	expectError(t, err, `alias "Bad": invalid path format`)
+
+	err = registerAlias(t, "Bad", "MSH[2]-3")
+	expectError(t, err, `alias "Bad": if Segment is MSH, SegmentIndex must be 1`)
+}
+
+func TestExtractAliasPrecedence(t *testing.T) {
+	// an alias named like a segment of the message does not shadow it
+	err1 := registerAlias(t, "OBX", "PID-3.1")
+	resp, err2 := ExtractAlias(message, "OBX")
+	expectValue(t, "OBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F", resp, err1, err2)
+
+	// nor does one named like a full path, with or without the segment
+	err1 = registerAlias(t, "PID-5.1", "PID-3.1")
+	resp, err2 = ExtractAlias(message, "PID-5.1")
+	expectValue(t, "EVERYWOMAN", resp, err1, err2)
+	err1 = registerAlias(t, "EVN-2", "PID-3.1")
+	resp, err2 = ExtractAlias(message, "EVN-2")
+	expectValue(t, "", resp, err1, err2)
+
+	// explicit paths work without being registered at all
+	resp, err2 = ExtractAlias(message, "PV1-2")
+	expectValue(t, "I", resp, err2)
+
+	// anything that isn't a path is looked up as an alias
+	err1 = registerAlias(t, "obx", "OBX[2]-5")
+	resp, err2 = ExtractAlias(message, "obx")
+	expectValue(t, "79", resp, err1, err2)
+
+	// a segment name missing from the message is read as the alias, or as
+	// the (empty) segment if there is no such alias
+	err1 = registerAlias(t, "MRN", "PID-3.1")
+	resp, err2 = ExtractAlias(message, "MRN")
+	expectValue(t, "555-44-4444", resp, err1, err2)
+	resp, err2 = ExtractAlias(message, "EVN")
+	expectValue(t, "", resp, err2)
+}
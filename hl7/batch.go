@@ -0,0 +1,73 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckUniqueControlIDs returns every MSH-10 control ID that is used by more
+// than one message of the batch, in the order they were first duplicated.
+// Messages without a control ID are not considered duplicates of each other.
+func CheckUniqueControlIDs(messages []string) ([]string, error) {
+	var duplicates []string
+	seen := map[string]int{}
+	for i, message := range messages {
+		id, err := MessageControlID(message)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if id == "" {
+			continue
+		}
+		seen[id]++
+		if seen[id] == 2 {
+			duplicates = append(duplicates, id)
+		}
+	}
+	return duplicates, nil
+}
+
+// batchSegments are the file and batch header and trailer segments that wrap
+// the messages of a batch file.
+var batchSegments = map[string]bool{"FHS": true, "BHS": true, "BTS": true, "FTS": true}
+
+// SplitBatch splits the content of a batch file into its messages, each
+// starting with its MSH segment. File and batch headers and trailers (FHS,
+// BHS, BTS and FTS) are dropped, and content without them, just messages one
+// after another, works too. Segments keep the terminators they had in the
+// file, blank lines are dropped. A batch without messages returns an empty
+// slice, anything other than a batch segment before the first MSH is an
+// error.
+func SplitBatch(content string) ([]string, error) {
+	messages := []string{}
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+	}
+	lines, terminators := splitSegmentsKeepingTerminators(content)
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		name := line
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		switch {
+		case batchSegments[name]:
+			flush()
+			continue
+		case name == "MSH":
+			flush()
+		case current.Len() == 0:
+			return nil, fmt.Errorf("unexpected segment %q before the first MSH", name)
+		}
+		current.WriteString(line)
+		current.WriteString(terminators[i])
+	}
+	flush()
+	return messages, nil
+}
@@ -0,0 +1,56 @@
+package hl7
+
+import "testing"
+
+func withControlID(id string) string {
+	return "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|" + id + "|P|2.5\rPID|1"
+}
+
+func TestCheckUniqueControlIDs(t *testing.T) {
+	duplicates, err := CheckUniqueControlIDs([]string{
+		withControlID("1"),
+		withControlID("2"),
+		withControlID("3"),
+	})
+	expectDeepValue(t, []string(nil), duplicates, err)
+
+	duplicates, err = CheckUniqueControlIDs([]string{
+		withControlID("2"),
+		withControlID("1"),
+		withControlID("1"),
+		withControlID(""),
+		withControlID("2"),
+		withControlID("1"),
+		withControlID(""),
+	})
+	expectDeepValue(t, []string{"1", "2"}, duplicates, err)
+
+	_, err = CheckUniqueControlIDs([]string{withControlID("1"), "PID|1"})
+	expectError(t, err, "message 1: invalid HL7 message: must begin with MSH")
+}
+
+func TestSplitBatch(t *testing.T) {
+	first := withControlID("1") + "\r"
+	second := withControlID("2") + "\r"
+
+	messages, err := SplitBatch("FHS|^~\\&|HIS\rBHS|^~\\&|HIS\r" + first + second + "BTS|2\rFTS|1\r")
+	expectDeepValue(t, []string{first, second}, messages, err)
+
+	// several batches in one file
+	messages, err = SplitBatch("FHS|^~\\&\rBHS|^~\\&\r" + first + "BTS|1\rBHS|^~\\&\r" + second + "BTS|1\rFTS|2")
+	expectDeepValue(t, []string{first, second}, messages, err)
+
+	// messages one after another, terminators are kept but blank lines are
+	// not
+	messages, err = SplitBatch(withControlID("1") + "\r\n\r\n" + withControlID("2"))
+	expectDeepValue(t, []string{withControlID("1") + "\r\n", withControlID("2")}, messages, err)
+
+	messages, err = SplitBatch("FHS|^~\\&\rBHS|^~\\&\rBTS|0\rFTS|1\r")
+	expectDeepValue(t, []string{}, messages, err)
+
+	messages, err = SplitBatch("")
+	expectDeepValue(t, []string{}, messages, err)
+
+	_, err = SplitBatch("FHS|^~\\&\rPID|1\r" + first)
+	expectError(t, err, `unexpected segment "PID" before the first MSH`)
+}
@@ -0,0 +1,185 @@
+package hl7
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// largeMessage is the sample message with 500 extra OBX segments before the
+// custom ZZZ segments, roughly the size of a big lab result.
+var largeMessage = func() string {
+	segments := strings.Split(message, "\r")
+	var b strings.Builder
+	b.WriteString(strings.Join(segments[:5], "\r"))
+	for i := 3; i < 503; i++ {
+		b.WriteString("\rOBX|" + strconv.Itoa(i) + "|NM|^Glucose||" + strconv.Itoa(70+i%50) + "|mg/dL|70-110|N|||F")
+	}
+	b.WriteString("\r" + strings.Join(segments[5:], "\r"))
+	return b.String()
+}()
+
+var benchmarkMessages = []struct {
+	name    string
+	message string
+}{
+	{"small", message},
+	{"large", largeMessage},
+}
+
+var benchmarkPaths = []HL7Path{
+	MustParsePath("MSH-10"),
+	MustParsePath("PID-3[2].1"),
+	MustParsePath("PID-5.1"),
+	MustParsePath("PV1-3.1"),
+	MustParsePath("OBX[2].5"),
+	MustParsePath("ZZZ[2].4"),
+}
+
+func BenchmarkAbstractHL7(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				_, _ = AbstractHL7(m.message, path)
+			}
+		})
+	}
+}
+
+func BenchmarkAbstractHL7View(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				_, _ = AbstractHL7View(m.message, path)
+			}
+		})
+	}
+}
+
+// BenchmarkAbstractHL7Bytes reads from a byte slice in place, compare with
+// the string conversion of BenchmarkAbstractHL7BytesToString.
+func BenchmarkAbstractHL7Bytes(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		data := []byte(m.message)
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_, _ = AbstractHL7Bytes(data, path)
+			}
+		})
+	}
+}
+
+func BenchmarkAbstractHL7BytesToString(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		data := []byte(m.message)
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				value, _ := AbstractHL7(string(data), path)
+				_ = []byte(value)
+			}
+		})
+	}
+}
+
+// BenchmarkAbstractHL7Batch extracts several paths from each message, the
+// way a transformation typically reads a message.
+func BenchmarkAbstractHL7Batch(b *testing.B) {
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				for _, path := range benchmarkPaths {
+					_, _ = AbstractHL7(m.message, path)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkFullParse visits every leaf of the message.
+func BenchmarkFullParse(b *testing.B) {
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				_, _ = LeafCount(m.message)
+			}
+		})
+	}
+}
+
+func BenchmarkAbstractHL7Metrics(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				_, _, _ = AbstractHL7Metrics(m.message, path)
+			}
+		})
+	}
+}
+
+// BenchmarkAbstractHL7Many extracts the same paths as
+// BenchmarkAbstractHL7Batch in a single call.
+func BenchmarkAbstractHL7Many(b *testing.B) {
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				_, _ = AbstractHL7Many(m.message, benchmarkPaths)
+			}
+		})
+	}
+}
+
+// BenchmarkExtractor extracts the same paths as BenchmarkAbstractHL7Batch
+// from an Extractor created for each message.
+func BenchmarkExtractor(b *testing.B) {
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(m.message)))
+			for i := 0; i < b.N; i++ {
+				e, _ := NewExtractor(m.message)
+				for _, path := range benchmarkPaths {
+					_, _ = e.Get(path)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkExtractorGet is the cost of a single Get, the message is only
+// split once.
+func BenchmarkExtractorGet(b *testing.B) {
+	path := MustParsePath("ZZZ[2].4")
+	for _, m := range benchmarkMessages {
+		b.Run(m.name, func(b *testing.B) {
+			e, err := NewExtractor(m.message)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = e.Get(path)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Builder builds a message from scratch one segment at a time, e.g.
+//
+//	b := NewBuilder(DefaultSeparators)
+//	b.Segment("MSH").Set(3, "MyApp").SetComponent(9, 1, "ADT").SetComponent(9, 2, "A01")
+//	b.Segment("PID").SetRepetitions(3, "123", "456").SetComponent(5, 1, "DOE")
+//	message, err := b.Build()
+//
+// MSH-1 and MSH-2 are written from the encoding, every value set is escaped
+// with it and pieces before the ones set are padded with empty ones. The
+// first segment has to be MSH and it can't appear again. Mistakes are kept
+// until Build, so calls can be chained.
+type Builder struct {
+	enc      Encoding
+	segments []*SegmentBuilder
+	err      error
+}
+
+// SegmentBuilder sets the fields of one segment of a Builder.
+type SegmentBuilder struct {
+	b    *Builder
+	name string
+	text string
+}
+
+// NewBuilder starts an empty message with the encoding characters enc.
+func NewBuilder(enc Encoding) *Builder {
+	return &Builder{enc: enc}
+}
+
+// Segment adds a segment named name after the ones already added and
+// returns it.
+func (b *Builder) Segment(name string) *SegmentBuilder {
+	s := &SegmentBuilder{b: b, name: name, text: name}
+	if _, err := parseSegmentNameOrError(name); err != nil {
+		b.fail(fmt.Errorf("segment %q: %w", name, err))
+	}
+	switch {
+	case len(b.segments) == 0 && name != "MSH":
+		b.fail(fmt.Errorf("segment %s: the first segment must be MSH", name))
+	case len(b.segments) > 0 && name == "MSH":
+		b.fail(errors.New("MSH can only be the first segment"))
+	}
+	if name == "MSH" {
+		s.text = "MSH" + string(b.enc.Field) + b.enc.String()
+	}
+	b.segments = append(b.segments, s)
+	return s
+}
+
+// Set sets field to value, replacing any repetitions, components and
+// subcomponents it had.
+func (s *SegmentBuilder) Set(field int, value string) *SegmentBuilder {
+	return s.setRaw(HL7Path{Field: field}, Escape(value, s.b.enc))
+}
+
+// SetRepetitions sets the repetitions of field to values, one each.
+func (s *SegmentBuilder) SetRepetitions(field int, values ...string) *SegmentBuilder {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = Escape(value, s.b.enc)
+	}
+	return s.setRaw(HL7Path{Field: field}, strings.Join(escaped, string(s.b.enc.Repetition)))
+}
+
+// SetComponent sets component of the first repetition of field to value.
+func (s *SegmentBuilder) SetComponent(field int, component int, value string) *SegmentBuilder {
+	if component < 1 {
+		return s.invalidPosition()
+	}
+	return s.set(HL7Path{Field: field, RepetitionIndex: 1, Component: component}, value)
+}
+
+// SetSubcomponent sets subcomponent of component of the first repetition of
+// field to value.
+func (s *SegmentBuilder) SetSubcomponent(field int, component int, subcomponent int, value string) *SegmentBuilder {
+	if component < 1 || subcomponent < 1 {
+		return s.invalidPosition()
+	}
+	return s.set(HL7Path{Field: field, RepetitionIndex: 1, Component: component, Subcomponent: subcomponent}, value)
+}
+
+func (s *SegmentBuilder) set(path HL7Path, value string) *SegmentBuilder {
+	return s.setRaw(path, Escape(value, s.b.enc))
+}
+
+// setRaw sets the already escaped value at path, a path without a repetition
+// index is the whole field.
+func (s *SegmentBuilder) setRaw(path HL7Path, value string) *SegmentBuilder {
+	path.Segment, path.SegmentIndex = s.name, 1
+	if path.Field < 1 {
+		return s.invalidPosition()
+	}
+	if s.name == "MSH" && path.Field <= 2 {
+		s.b.fail(errors.New("MSH-1 and MSH-2 are written from the encoding and can't be set"))
+		return s
+	}
+	if path.RepetitionIndex == 0 {
+		s.text = replaceField(s.text, path, value, s.b.enc)
+		return s
+	}
+	text, err := setRawInSegment(s.text, path, value, s.b.enc)
+	if err != nil {
+		s.b.fail(err)
+		return s
+	}
+	s.text = text
+	return s
+}
+
+func (s *SegmentBuilder) invalidPosition() *SegmentBuilder {
+	s.b.fail(fmt.Errorf("segment %s: fields, components and subcomponents are numbered from 1", s.name))
+	return s
+}
+
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the message with every segment terminated by \r, or the
+// first mistake made building it. The header is validated like every other
+// function does, see ValidateMSH, which catches invalid encoding characters.
+func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	if len(b.segments) == 0 {
+		return "", errors.New("message has no segments, the first one must be MSH")
+	}
+	message := b.String()
+	if _, err := ValidateMSH(message); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// String returns the message as built so far, without the checks of Build.
+func (b *Builder) String() string {
+	var sb strings.Builder
+	for _, s := range b.segments {
+		sb.WriteString(s.text)
+		sb.WriteByte('\r')
+	}
+	return sb.String()
+}
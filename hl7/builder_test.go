@@ -0,0 +1,63 @@
+package hl7
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(DefaultSeparators)
+	b.Segment("MSH").Set(3, "HIS").Set(4, "RIH").Set(7, "20060529090131").
+		SetComponent(9, 1, "ADT").SetComponent(9, 2, "A01").Set(10, "MSG00001").Set(11, "P").Set(12, "2.5")
+	b.Segment("PID").SetRepetitions(3, "555-44-4444", "123").SetComponent(3, 5, "SSN").
+		SetComponent(5, 1, "EVERYWOMAN").SetComponent(5, 2, "EVE").Set(7, "19610615")
+	b.Segment("OBX").Set(1, "1").SetSubcomponent(3, 2, 2, "Height").Set(5, "1.80")
+	message, err := b.Build()
+	expectValue(t, "MSH|^~\\&|HIS|RIH|||20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||555-44-4444^^^^SSN~123||EVERYWOMAN^EVE||19610615\rOBX|1||^&Height||1.80\r", message, err)
+	expectValue(t, message, b.String())
+
+	// values are escaped, a set replaces what was there
+	b = NewBuilder(Encoding{Field: '#', Component: '!', Repetition: '@', Escape: '$', Subcomponent: '%', Truncation: '*'})
+	b.Segment("MSH").Set(3, "HIS")
+	b.Segment("NTE").Set(3, "A#B!C").SetComponent(3, 2, "D").SetRepetitions(4, "old", "older").Set(4, "a*b\rc").Set(5, "old~").SetRepetitions(5, "x@y", "$")
+	message, err = b.Build()
+	expectValue(t, "MSH#!@$%*#HIS\rNTE###A$F$B$S$C!D#a$P$b$X0D$c#x$R$y@$E$\r", message, err)
+	resp, err := AbstractHL7Opts(message, MustParsePath("NTE-3.1"), WithUnescape())
+	expectValue(t, "A#B!C", resp, err)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	b := NewBuilder(DefaultSeparators)
+	b.Segment("PID").Set(1, "1")
+	_, err := b.Build()
+	expectError(t, err, "segment PID: the first segment must be MSH")
+
+	b = NewBuilder(DefaultSeparators)
+	b.Segment("MSH").Set(3, "HIS")
+	b.Segment("MSH")
+	_, err = b.Build()
+	expectError(t, err, "MSH can only be the first segment")
+
+	// the first mistake is the one reported
+	b = NewBuilder(DefaultSeparators)
+	b.Segment("MSH").Set(2, "^~\\&").Set(0, "x")
+	_, err = b.Build()
+	expectError(t, err, "MSH-1 and MSH-2 are written from the encoding and can't be set")
+
+	b = NewBuilder(DefaultSeparators)
+	b.Segment("MSH").Set(3, "HIS")
+	b.Segment("pid")
+	_, err = b.Build()
+	expectError(t, err, `segment "pid": segment name must begin with an uppercase letter`)
+
+	b = NewBuilder(DefaultSeparators)
+	b.Segment("MSH").Set(3, "HIS")
+	b.Segment("PID").SetComponent(5, 0, "x")
+	_, err = b.Build()
+	expectError(t, err, "segment PID: fields, components and subcomponents are numbered from 1")
+
+	_, err = NewBuilder(DefaultSeparators).Build()
+	expectError(t, err, "message has no segments, the first one must be MSH")
+
+	b = NewBuilder(Encoding{Field: '|', Component: '^', Repetition: '^', Escape: '\\', Subcomponent: '&'})
+	b.Segment("MSH").Set(3, "HIS")
+	_, err = b.Build()
+	expectError(t, err, "component and repetition separators are identical: both '^'")
+}
@@ -0,0 +1,83 @@
+package hl7
+
+import "strings"
+
+// Canonicalize rewrites a message to use the standard |^~\& separators and
+// terminates every segment with \r. Data that contains one of the standard
+// separators (only possible when the message declared different ones) is
+// escaped, and escape sequences are rewritten to use the standard escape
+// character so the values read back exactly as they did before.
+func Canonicalize(message string) (string, error) {
+	src, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.Grow(len(message))
+	for i, segment := range segmentLines(message) {
+		if i == 0 {
+			// MSH-1 and MSH-2 are replaced outright, the rest of the segment
+			// is data like any other.
+			b.WriteString("MSH|^~\\&")
+			if end := strings.IndexByte(segment[4:], src.Field); end != -1 {
+				segment = segment[4+end:]
+			} else {
+				segment = ""
+			}
+		}
+		canonicalizeSegment(&b, segment, src)
+		b.WriteByte('\r')
+	}
+	return b.String(), nil
+}
+
+// canonicalizeSegment writes a segment using the standard separators.
+func canonicalizeSegment(b *strings.Builder, segment string, src Encoding) {
+	std := DefaultSeparators
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		switch c {
+		case src.Field:
+			b.WriteByte(std.Field)
+		case src.Component:
+			b.WriteByte(std.Component)
+		case src.Repetition:
+			b.WriteByte(std.Repetition)
+		case src.Subcomponent:
+			b.WriteByte(std.Subcomponent)
+		case src.Escape:
+			end := strings.IndexByte(segment[i+1:], src.Escape)
+			if end == -1 {
+				// not a sequence, just a literal escape character
+				writeCanonicalData(b, c)
+				continue
+			}
+			b.WriteByte(std.Escape)
+			b.WriteString(segment[i+1 : i+1+end])
+			b.WriteByte(std.Escape)
+			i += end + 1
+		default:
+			writeCanonicalData(b, c)
+		}
+	}
+}
+
+// writeCanonicalData writes a data byte, escaping it if it is one of the
+// standard separators.
+func writeCanonicalData(b *strings.Builder, c byte) {
+	switch c {
+	case '|':
+		b.WriteString(`\F\`)
+	case '^':
+		b.WriteString(`\S\`)
+	case '~':
+		b.WriteString(`\R\`)
+	case '\\':
+		b.WriteString(`\E\`)
+	case '&':
+		b.WriteString(`\T\`)
+	default:
+		b.WriteByte(c)
+	}
+}
@@ -0,0 +1,30 @@
+package hl7
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	// already canonical apart from the segment terminators
+	resp, err := Canonicalize("MSH|^~\\&|HIS|RIH\nPID|||123^^^^MRN\r\nPV1||I")
+	expectValue(t, "MSH|^~\\&|HIS|RIH\rPID|||123^^^^MRN\rPV1||I\r", resp, err)
+
+	// custom separators with data that collides with the standard ones
+	custom := "MSH*%$!@*HIS*RIH*EKG*EKG*20060529090131**ADT%A01*MSG00001*P*2.5\r" +
+		"PID***123%%%%MRN$456%%%%SSN**EVERY|WOMAN%EVE^E\r" +
+		"NTE*1**pipe!F!star a@b!E!c \\d ~e&f\r"
+	resp, err = Canonicalize(custom)
+	expectValue(t,
+		"MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\r"+
+			"PID|||123^^^^MRN~456^^^^SSN||EVERY\\F\\WOMAN^EVE\\S\\E\r"+
+			"NTE|1||pipe\\F\\star a&b\\E\\c \\E\\d \\R\\e\\T\\f\r",
+		resp, err,
+	)
+
+	// values read back the same once canonical
+	path, err1 := ParsePath("PID-3[2].5")
+	before, err2 := AbstractHL7(custom, path)
+	after, err3 := AbstractHL7(resp, path)
+	expectValue(t, before, after, err1, err2, err3)
+
+	_, err = Canonicalize("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
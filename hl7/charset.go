@@ -0,0 +1,83 @@
+package hl7
+
+import (
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// charsets maps the HL7 table 0211 character set names to their encodings. A
+// nil encoding means the bytes are already valid UTF-8 and need no decoding.
+var charsets = map[string]encoding.Encoding{
+	"":               nil,
+	"ASCII":          nil,
+	"UNICODE":        nil,
+	"UNICODE UTF-8":  nil,
+	"UTF-8":          nil, // not in the table, but what many senders write
+	"UNICODE UTF-16": unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"UNICODE UTF-32": utf32.UTF32(utf32.BigEndian, utf32.UseBOM),
+	"8859/1":         charmap.ISO8859_1,
+	"8859/2":         charmap.ISO8859_2,
+	"8859/3":         charmap.ISO8859_3,
+	"8859/4":         charmap.ISO8859_4,
+	"8859/5":         charmap.ISO8859_5,
+	"8859/6":         charmap.ISO8859_6,
+	"8859/7":         charmap.ISO8859_7,
+	"8859/8":         charmap.ISO8859_8,
+	"8859/9":         charmap.ISO8859_9,
+	"8859/15":        charmap.ISO8859_15,
+	"ISO IR87":       japanese.ISO2022JP,
+	"GB 18030-2000":  simplifiedchinese.GB18030,
+	"KS X 1001":      korean.EUCKR,
+	"BIG-5":          traditionalchinese.Big5,
+}
+
+// CharacterSet returns the character set declared in MSH-18. When a message
+// declares more than one, the first repetition is the default character set
+// of the message and is the one returned. An empty result means the message
+// did not declare one, in which case ASCII is assumed.
+func CharacterSet(message string) (string, error) {
+	return AbstractHL7(message, HL7Path{
+		Segment:         "MSH",
+		SegmentIndex:    1,
+		Field:           18,
+		RepetitionIndex: 1,
+	})
+}
+
+// DecodeCharset transcodes the whole message to UTF-8 from the character set
+// its MSH-18 declares, see CharacterSet. A message without one is taken to be
+// ASCII and returned as it is, as is one that is already UTF-8. MSH-18 is
+// left as it was, so the result must not be decoded again.
+func DecodeCharset(message string) (string, error) {
+	charset, err := CharacterSet(message)
+	if err != nil {
+		return "", err
+	}
+	return DecodeValue(message, charset)
+}
+
+// DecodeValue transcodes a value from the given HL7 character set to UTF-8.
+// Values in ASCII or UTF-8 (or with no declared character set) are returned
+// unchanged.
+func DecodeValue(value string, charset string) (string, error) {
+	enc, ok := charsets[charset]
+	if !ok {
+		return "", fmt.Errorf("unsupported character set %q", charset)
+	}
+	if enc == nil {
+		return value, nil
+	}
+	decoded, err := enc.NewDecoder().String(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode value from %s: %w", charset, err)
+	}
+	return decoded, nil
+}
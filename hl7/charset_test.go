@@ -0,0 +1,53 @@
+package hl7
+
+import "testing"
+
+func TestCharacterSet(t *testing.T) {
+	charset, err := CharacterSet(message)
+	expectValue(t, "", charset, err)
+
+	latin1 := "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5||||||8859/1~UNICODE UTF-8\rPID|||123||M\xfcLLER^J\xd6RG"
+	charset, err = CharacterSet(latin1)
+	expectValue(t, "8859/1", charset, err)
+
+	_, err = CharacterSet("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestDecodeValue(t *testing.T) {
+	value, err := DecodeValue("M\xfcLLER", "8859/1")
+	expectValue(t, "MüLLER", value, err)
+
+	value, err = DecodeValue("J\xd6RG", "8859/15")
+	expectValue(t, "JÖRG", value, err)
+
+	// ASCII and UTF-8 values pass through untouched
+	value, err = DecodeValue("MüLLER", "UNICODE UTF-8")
+	expectValue(t, "MüLLER", value, err)
+
+	value, err = DecodeValue("EVERYWOMAN", "")
+	expectValue(t, "EVERYWOMAN", value, err)
+
+	_, err = DecodeValue("EVERYWOMAN", "EBCDIC")
+	expectError(t, err, `unsupported character set "EBCDIC"`)
+}
+
+func TestDecodeCharset(t *testing.T) {
+	latin1 := "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5||||||8859/1\rPID|||123||M\xfcLLER^J\xd6RG"
+	decoded, err := DecodeCharset(latin1)
+	expectValue(t, "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5||||||8859/1\rPID|||123||MüLLER^JÖRG", decoded, err)
+	resp, err := AbstractHL7(decoded, MustParsePath("PID-5.2"))
+	expectValue(t, "JÖRG", resp, err)
+
+	// no MSH-18 is ASCII, UTF-8 is already decoded
+	decoded, err = DecodeCharset(message)
+	expectValue(t, message, decoded, err)
+	utf8 := "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5||||||UTF-8\rPID|||123||MüLLER"
+	decoded, err = DecodeCharset(utf8)
+	expectValue(t, utf8, decoded, err)
+
+	_, err = DecodeCharset("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5||||||EBCDIC\rPID|1")
+	expectError(t, err, `unsupported character set "EBCDIC"`)
+	_, err = DecodeCharset("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
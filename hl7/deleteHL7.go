@@ -0,0 +1,65 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+// DeleteHL7 returns a copy of message with the piece path references removed.
+// Deleting a field removes it along with all of its repetitions and shifts the
+// fields after it one to the left, so PID-6 becomes PID-5 after deleting
+// PID-5. Deleting a component or subcomponent removes just that piece from
+// the referenced repetition in the same way. Deleting something that doesn't
+// exist leaves the message unchanged. MSH-1 and MSH-2 can't be deleted.
+func DeleteHL7(message string, path HL7Path) (string, error) {
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		return "", errors.New("MSH-1 and MSH-2 hold the encoding characters and can't be deleted")
+	}
+	if path.Segment != "" && path.Field == 0 {
+		return "", errors.New("path must reference a field")
+	}
+	return editSegment(message, path, func(segment string, sep Encoding) (string, error) {
+		return deleteInSegment(segment, path, sep), nil
+	})
+}
+
+func deleteInSegment(segment string, path HL7Path, sep Encoding) string {
+	fields := strings.Split(segment, string(sep.Field))
+	i := path.Field
+	if path.Segment == "MSH" {
+		i--
+	}
+	if i >= len(fields) {
+		return segment
+	}
+	if path.Component == 0 {
+		fields = append(fields[:i], fields[i+1:]...)
+		return strings.Join(fields, string(sep.Field))
+	}
+
+	repetitions := strings.Split(fields[i], string(sep.Repetition))
+	r := resolveIndex(path.RepetitionIndex, len(repetitions)) - 1
+	if r < 0 || r >= len(repetitions) {
+		return segment
+	}
+	// separators in escape sequences are data, like AbstractHL7 reads them
+	components := splitEscaped(repetitions[r], sep.Component, sep.Escape)
+	c := path.Component - 1
+	if c >= len(components) {
+		return segment
+	}
+	if path.Subcomponent == 0 {
+		components = append(components[:c], components[c+1:]...)
+	} else {
+		subcomponents := splitEscaped(components[c], sep.Subcomponent, sep.Escape)
+		s := path.Subcomponent - 1
+		if s >= len(subcomponents) {
+			return segment
+		}
+		subcomponents = append(subcomponents[:s], subcomponents[s+1:]...)
+		components[c] = strings.Join(subcomponents, string(sep.Subcomponent))
+	}
+	repetitions[r] = strings.Join(components, string(sep.Component))
+	fields[i] = strings.Join(repetitions, string(sep.Repetition))
+	return strings.Join(fields, string(sep.Field))
+}
@@ -0,0 +1,61 @@
+package hl7
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeleteHL7(t *testing.T) {
+	path, err1 := ParsePath("PID-5")
+	resp, err2 := DeleteHL7(message, path)
+	expectValue(t, strings.Replace(message, "EVERYWOMAN^EVE^E^^^^L~QUE^SUZY^^^^^N|", "", 1), resp, err1, err2)
+
+	// later fields shift left
+	resp, err1 = AbstractHL7(resp, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 6, RepetitionIndex: 1})
+	expectValue(t, "19610615", resp, err1)
+
+	path, err1 = ParsePath("MSH-3")
+	resp, err2 = DeleteHL7(message, path)
+	expectValue(t, strings.Replace(message, "|HIS|", "|", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5[2].1")
+	resp, err2 = DeleteHL7(message, path)
+	expectValue(t, strings.Replace(message, "~QUE^SUZY^", "~SUZY^", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("ZZZ-2[2].2.2")
+	resp, err2 = DeleteHL7(message, path)
+	expectValue(t, strings.Replace(message, "custom&segment&with", "custom&with", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5[-1].1")
+	resp, err2 = DeleteHL7(message, path)
+	expectValue(t, strings.Replace(message, "~QUE^SUZY^", "~SUZY^", 1), resp, err1, err2)
+
+	// nothing to delete
+	for _, p := range []string{"PID-40", "PID-3[3].1", "PID-3[-3].1", "PV1-2.5", "PV1-2.1.3"} {
+		path, err1 = ParsePath(p)
+		resp, err2 = DeleteHL7(message, path)
+		expectValue(t, message, resp, err1, err2)
+	}
+}
+
+func TestDeleteHL7EscapedSeparators(t *testing.T) {
+	// separators inside escape sequences are data, like AbstractHL7 reads them
+	resp, err := DeleteHL7("MSH|^~\\&|HIS\rZZZ|1|C\\Zx^y\\D^F^G", MustParsePath("ZZZ-2.2"))
+	expectValue(t, "MSH|^~\\&|HIS\rZZZ|1|C\\Zx^y\\D^G", resp, err)
+	resp, err = DeleteHL7("MSH|^~\\&|HIS\rZZZ|1|A\\Zx&y\\B&C", MustParsePath("ZZZ-2.1.1"))
+	expectValue(t, "MSH|^~\\&|HIS\rZZZ|1|C", resp, err)
+}
+
+func TestDeleteHL7Errors(t *testing.T) {
+	path, _ := ParsePath("MSH-2")
+	_, err := DeleteHL7(message, path)
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be deleted")
+
+	path, _ = ParsePath("PID")
+	_, err = DeleteHL7(message, path)
+	expectError(t, err, "path must reference a field")
+
+	path, _ = ParsePath("OBX[3]-1")
+	_, err = DeleteHL7(message, path)
+	expectError(t, err, "segment OBX[3] not found")
+}
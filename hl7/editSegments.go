@@ -0,0 +1,119 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AppendSegment returns a copy of message with segment added as its last
+// segment. The new segment is terminated like most segments of the message
+// are, see DetectSegmentSeparator, and a message that ended with a
+// terminator still does. segment must start with a segment name followed by
+// the field separator of the message, MSH can't be added.
+func AppendSegment(message string, segment string) (string, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+	if err := checkNewSegment(segment, sep); err != nil {
+		return "", err
+	}
+	segments, terminators := splitSegmentsKeepingTerminators(message)
+	terminator := dominantTerminator(terminators)
+	last := len(segments) - 1
+	if segments[last] == "" {
+		// the message ends with a terminator, so does the result
+		segments[last], terminators[last] = segment, terminator
+	} else {
+		terminators[last] = terminator
+		segments, terminators = append(segments, segment), append(terminators, "")
+	}
+	return joinSegments(segments, terminators), nil
+}
+
+// InsertSegment returns a copy of message with segment inserted before the
+// segment at index, so it becomes the index-th segment. Indexes are 1-based
+// like segment indexes in paths and blank lines don't count: inserting an EVN
+// at index 2 puts it right after MSH. Index 1 is MSH, which always stays
+// first, and an index one past the last segment appends like AppendSegment.
+// The new segment is terminated like most segments of the message are and
+// must use its field separator.
+func InsertSegment(message string, index int, segment string) (string, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+	if err := checkNewSegment(segment, sep); err != nil {
+		return "", err
+	}
+	if index < 2 {
+		return "", fmt.Errorf("index %d is out of range, MSH is always the first segment", index)
+	}
+	segments, terminators := splitSegmentsKeepingTerminators(message)
+	count := 0
+	for i, line := range segments {
+		if line == "" {
+			continue
+		}
+		count++
+		if count == index {
+			segments = slices.Insert(segments, i, segment)
+			terminators = slices.Insert(terminators, i, dominantTerminator(terminators))
+			return joinSegments(segments, terminators), nil
+		}
+	}
+	if index == count+1 {
+		return AppendSegment(message, segment)
+	}
+	return "", fmt.Errorf("index %d is out of range, the message has %d segments", index, count)
+}
+
+// DeleteSegment returns a copy of message without the index-th (1-based, or
+// counted from the end when negative) segment named segment, the rest of the
+// message is left as it was. If there is no such segment the error matches
+// ErrSegmentNotFound. MSH can't be deleted.
+func DeleteSegment(message string, segment string, index int) (string, error) {
+	if _, err := parseSegmentNameOrError(segment); err != nil {
+		return "", err
+	}
+	if segment == "MSH" {
+		return "", errors.New("MSH can't be deleted")
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+	segments, terminators := splitSegmentsKeepingTerminators(message)
+	i := findSegment(segments, HL7Path{Segment: segment, SegmentIndex: index}, sep)
+	if i == -1 {
+		return "", &kindError{ErrSegmentNotFound, fmt.Errorf("segment %s[%d] not found", segment, index)}
+	}
+	if terminators[i] == "" {
+		// the last segment, the one before it becomes the last
+		terminators[i-1] = ""
+	}
+	segments = slices.Delete(segments, i, i+1)
+	terminators = slices.Delete(terminators, i, i+1)
+	return joinSegments(segments, terminators), nil
+}
+
+// checkNewSegment validates a segment that is about to be added to a message
+// with the separators sep.
+func checkNewSegment(segment string, sep Encoding) error {
+	if strings.ContainsAny(segment, "\r\n") {
+		return errors.New("segment must be a single line")
+	}
+	name, rest, _ := strings.Cut(segment, string(sep.Field))
+	if _, err := parseSegmentNameOrError(name); err != nil {
+		if len(segment) > 3 && rest == "" {
+			return fmt.Errorf("segment %q must separate its fields with %q, the field separator of the message", segment, sep.Field)
+		}
+		return err
+	}
+	if name == "MSH" {
+		return errors.New("a message has only one MSH segment")
+	}
+	return nil
+}
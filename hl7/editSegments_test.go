@@ -0,0 +1,97 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppendSegment(t *testing.T) {
+	msg, err := AppendSegment(message, "NTE|1||a note")
+	expectValue(t, message+"\rNTE|1||a note", msg, err)
+
+	// the terminator of the message is used and kept at the end
+	msg, err = AppendSegment("MSH|^~\\&|HIS\r\nPID|1\r\n", "NTE|1")
+	expectValue(t, "MSH|^~\\&|HIS\r\nPID|1\r\nNTE|1\r\n", msg, err)
+
+	msg, err = AppendSegment("MSH|^~\\&|HIS", "EVN")
+	expectValue(t, "MSH|^~\\&|HIS\rEVN", msg, err)
+
+	msg, err = AppendSegment("MSH#^~\\&#HIS\nPID#1", "ZPI#a|b")
+	expectValue(t, "MSH#^~\\&#HIS\nPID#1\nZPI#a|b", msg, err)
+
+	_, err = AppendSegment("MSH#^~\\&#HIS", "ZPI|a")
+	expectError(t, err, `segment "ZPI|a" must separate its fields with '#', the field separator of the message`)
+
+	_, err = AppendSegment(message, "nte|1")
+	expectError(t, err, "segment name must begin with an uppercase letter")
+
+	_, err = AppendSegment(message, "NTE|1\rNTE|2")
+	expectError(t, err, "segment must be a single line")
+
+	_, err = AppendSegment(message, "MSH|^~\\&|HIS")
+	expectError(t, err, "a message has only one MSH segment")
+
+	_, err = AppendSegment("PID|1", "NTE|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestInsertSegment(t *testing.T) {
+	msg, err := InsertSegment(message, 2, "EVN|A01|20060529090131")
+	expectValue(t, true, strings.HasPrefix(msg, "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rEVN|A01|20060529090131\rPID|"), err)
+	names, err := SegmentNames(msg)
+	expectDeepValue(t, []string{"MSH", "EVN", "PID", "PV1", "OBX", "OBX", "ZZZ", "ZZZ"}, names, err)
+
+	// before the last segment and after it
+	msg, err = InsertSegment(message, 7, "NTE|1")
+	expectValue(t, nil, err)
+	names, err = SegmentNames(msg)
+	expectDeepValue(t, []string{"MSH", "PID", "PV1", "OBX", "OBX", "ZZZ", "NTE", "ZZZ"}, names, err)
+
+	msg, err = InsertSegment(message, 8, "NTE|1")
+	expectValue(t, message+"\rNTE|1", msg, err)
+
+	// blank lines don't count and keep their place
+	msg, err = InsertSegment("MSH|^~\\&|HIS\r\n\r\nPID|1\r\n", 3, "PV1|1")
+	expectValue(t, "MSH|^~\\&|HIS\r\n\r\nPID|1\r\nPV1|1\r\n", msg, err)
+
+	msg, err = InsertSegment("MSH|^~\\&|HIS\n\nPID|1", 2, "EVN")
+	expectValue(t, "MSH|^~\\&|HIS\n\nEVN\nPID|1", msg, err)
+
+	_, err = InsertSegment(message, 1, "EVN|A01")
+	expectError(t, err, "index 1 is out of range, MSH is always the first segment")
+
+	_, err = InsertSegment(message, 9, "EVN|A01")
+	expectError(t, err, "index 9 is out of range, the message has 7 segments")
+
+	_, err = InsertSegment("MSH#^~\\&#HIS\rPID#1", 2, "EVN|A01")
+	expectError(t, err, `segment "EVN|A01" must separate its fields with '#', the field separator of the message`)
+}
+
+func TestDeleteSegment(t *testing.T) {
+	msg, err := DeleteSegment(message, "OBX", 1)
+	expectValue(t, strings.Replace(message, "OBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F\r", "", 1), msg, err)
+	obx, err := AbstractHL7Segments(msg, "OBX")
+	expectDeepValue(t, []string{"OBX|2|ST|^Body Weight||79|kg|50-100|N|||F"}, obx, err)
+
+	// the last segment, counted from the end
+	msg, err = DeleteSegment(message, "ZZZ", -1)
+	expectValue(t, strings.TrimSuffix(message, "\rZZZ||foo|bar|baz"), msg, err)
+
+	// terminators are kept
+	msg, err = DeleteSegment("MSH|^~\\&|HIS\r\nPID|1\r\nPV1|1\r\n", "PID", 1)
+	expectValue(t, "MSH|^~\\&|HIS\r\nPV1|1\r\n", msg, err)
+
+	_, err = DeleteSegment(message, "OBX", 3)
+	expectError(t, err, "segment OBX[3] not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+
+	_, err = DeleteSegment(message, "NTE", 1)
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+
+	_, err = DeleteSegment(message, "MSH", 1)
+	expectError(t, err, "MSH can't be deleted")
+
+	_, err = DeleteSegment(message, "Obx", 1)
+	expectError(t, err, "segment name must be uppercase alphanumeric")
+}
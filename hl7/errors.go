@@ -0,0 +1,96 @@
+package hl7
+
+import "errors"
+
+// Errors that can be matched with errors.Is. The errors returned keep their
+// own, more specific, text, e.g. "invalid HL7 message: must begin with MSH"
+// matches ErrInvalidMessage.
+var (
+	// ErrInvalidMessage is any problem with the MSH header of a message.
+	ErrInvalidMessage = errors.New("invalid HL7 message")
+	// ErrMissingComponentSeparator, and the three like it, are the invalid
+	// messages where MSH-2 is too short to declare every encoding character.
+	ErrMissingComponentSeparator    = errors.New("missing component separator")
+	ErrMissingRepetitionSeparator   = errors.New("missing repetition separator")
+	ErrMissingEscapeCharacter       = errors.New("missing escape character")
+	ErrMissingSubcomponentSeparator = errors.New("missing subcomponent separator")
+	// ErrInvalidPath is a path that can't be parsed or fails Validate.
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrSegmentNotFound is a path to a segment the message does not have,
+	// where that is an error rather than an empty value.
+	ErrSegmentNotFound = errors.New("segment not found")
+	// ErrValueNotFound is a path past the last field, repetition, component
+	// or subcomponent of its segment, where that is an error rather than an
+	// empty value.
+	ErrValueNotFound = errors.New("value not found")
+	// ErrSpecViolation is a message whose segments don't match the
+	// MessageSpec it was validated against.
+	ErrSpecViolation = errors.New("message does not match its spec")
+)
+
+// kindError gives err the identity of one of the errors above without
+// changing its text.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// Stages of an extraction reported by ExtractError.
+const (
+	// StagePath is validating the requested path.
+	StagePath = "path"
+	// StageHeader is validating the MSH header and reading the separators.
+	StageHeader = "header"
+	// StageValue is finding the value in the message, which only fails
+	// WithStrict.
+	StageValue = "value"
+)
+
+// Levels of a path reported by ExtractError.
+const (
+	LevelSegment      = "segment"
+	LevelField        = "field"
+	LevelRepetition   = "repetition"
+	LevelComponent    = "component"
+	LevelSubcomponent = "subcomponent"
+)
+
+// ExtractError is returned by AbstractHL7 when a message or path is
+// structurally invalid. It carries the requested path and the stage of the
+// extraction that failed, use errors.As to get at them. The error text is the
+// text of the wrapped error.
+//
+// A value missing from the message is an error WithStrict, at StageValue. The
+// error then also says where the path left the message: Segment is the
+// segment it was looking in, like OBX[2], Level the first level of the path
+// that is not in the message and Reason why, like "the field has 2
+// repetitions". The wrapped error matches ErrSegmentNotFound for a missing
+// segment and ErrValueNotFound for anything below it.
+type ExtractError struct {
+	Path    HL7Path
+	Stage   string
+	Segment string
+	Level   string
+	Reason  string
+	Err     error
+}
+
+func (e *ExtractError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,68 @@
+package hl7
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractError(t *testing.T) {
+	path := HL7Path{Segment: "MSH", SegmentIndex: 2}
+	_, err := AbstractHL7(message, path)
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+	var extractErr *ExtractError
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, StagePath, extractErr.Stage)
+	expectValue(t, path, extractErr.Path)
+
+	path, err1 := ParsePath("PID-3")
+	_, err = AbstractHL7("MSH|^^\\&|HIS", path)
+	expectError(t, err, "component and repetition separators are identical: both '^'")
+	expectValue(t, true, errors.As(err, &extractErr), err1)
+	expectValue(t, StageHeader, extractErr.Stage)
+	expectValue(t, path, extractErr.Path)
+
+	// the wrapped error is still reachable
+	_, err = AbstractHL7View("PID|1", path)
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, "invalid HL7 message: must begin with MSH", errors.Unwrap(err).Error())
+	expectValue(t, true, errors.Is(err, extractErr.Err))
+}
+
+func TestSentinelErrors(t *testing.T) {
+	path, _ := ParsePath("PID-3")
+	_, err := AbstractHL7("PID|1", path)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+	expectValue(t, false, errors.Is(err, ErrInvalidPath))
+
+	_, err = AbstractHL7("MSH||~\\&|HIS", path)
+	expectError(t, err, "missing component separator")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+	expectValue(t, true, errors.Is(err, ErrMissingComponentSeparator))
+	expectValue(t, false, errors.Is(err, ErrMissingRepetitionSeparator))
+
+	_, err = Parse("MSH|^~\\|HIS|RIH")
+	expectError(t, err, "missing subcomponent separator")
+	expectValue(t, true, errors.Is(err, ErrMissingSubcomponentSeparator))
+
+	_, err = Parse("MSH|^^\\&|HIS")
+	expectError(t, err, "component and repetition separators are identical: both '^'")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+
+	_, err = ParsePath("PID-3.1.2.3")
+	expectError(t, err, "invalid path format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+
+	_, err = ParsePath("pid")
+	expectError(t, err, "invalid path format")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+
+	_, err = AbstractHL7(message, HL7Path{Segment: "MSH", SegmentIndex: 2})
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+
+	path, _ = ParsePath("OBX[3]-5")
+	_, err = SetHL7(message, path, "1")
+	expectError(t, err, "segment OBX[3] not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+}
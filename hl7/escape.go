@@ -0,0 +1,195 @@
+package hl7
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+)
+
+// EscapeSequences returns every escape sequence found in value, including the
+// surrounding escape characters (e.g. `\F\`, `\H\`, `\X0D0A\`) in the order
+// they appear. An escape character without a closing escape character is not
+// a sequence and is ignored.
+func EscapeSequences(value string, separators Encoding) []string {
+	var sequences []string
+	esc := string(separators.Escape)
+	for {
+		start := strings.Index(value, esc)
+		if start == -1 {
+			break
+		}
+		end := strings.Index(value[start+1:], esc)
+		if end == -1 {
+			break
+		}
+		end += start + 2
+		sequences = append(sequences, value[start:end])
+		value = value[end:]
+	}
+	return sequences
+}
+
+// splitEscaped splits s at every sep like strings.Split, except for a sep
+// inside an escape sequence, from an escape character to the next one, which
+// is data. An escape character without a closing one is an ordinary
+// character.
+func splitEscaped(s string, sep byte, escape byte) []string {
+	if strings.IndexByte(s, escape) == -1 {
+		return strings.Split(s, string(sep))
+	}
+	var parts []string
+	for {
+		i := indexUnescaped(s, sep, escape)
+		if i == -1 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// indexUnescaped is strings.IndexByte skipping escape sequences, see
+// splitEscaped.
+func indexUnescaped(s string, sep byte, escape byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case escape:
+			if end := strings.IndexByte(s[i+1:], escape); end != -1 {
+				i += end + 1
+			}
+		case sep:
+			return i
+		}
+	}
+	return -1
+}
+
+// Escape replaces the characters of value that would otherwise change the
+// structure of a message with escape sequences, so a|b^c becomes a\F\b\S\c
+// with the default encoding. The escape character itself becomes \E\ and is
+// escaped before anything else, the sequences written are never escaped a
+// second time. The truncation character, if enc has one, becomes \P\.
+// Carriage returns and line feeds, which would end the segment, become \X0D\
+// and \X0A\.
+func Escape(value string, enc Encoding) string {
+	return escape(value, enc)
+}
+
+// escape is Escape that leaves the separators in keep alone, for values that
+// are meant to be split by them.
+func escape(value string, enc Encoding, keep ...byte) string {
+	var b strings.Builder
+	b.Grow(len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if bytes.IndexByte(keep, c) != -1 {
+			b.WriteByte(c)
+			continue
+		}
+		var code string
+		switch {
+		case c == enc.Escape:
+			code = "E"
+		case c == enc.Field:
+			code = "F"
+		case c == enc.Component:
+			code = "S"
+		case c == enc.Subcomponent:
+			code = "T"
+		case c == enc.Repetition:
+			code = "R"
+		case c == enc.Truncation && enc.Truncation != 0:
+			code = "P"
+		case c == '\r':
+			code = "X0D"
+		case c == '\n':
+			code = "X0A"
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte(enc.Escape)
+		b.WriteString(code)
+		b.WriteByte(enc.Escape)
+	}
+	return b.String()
+}
+
+// Unescape replaces the escape sequences in value with the characters they
+// stand for: \F\, \S\, \T\, \R\ and \E\ become the field, component,
+// subcomponent, repetition and escape characters of enc, \P\ becomes the
+// truncation character if enc has one, and \Xdd..\ becomes the bytes of its
+// hex digits. Other sequences, like the formatting ones
+// (\H\, \N\, \.br\, ...), are left as they are, see
+// UnescapeStripFormatting. So are malformed sequences and an escape character
+// without a closing one.
+func Unescape(value string, enc Encoding) string {
+	return unescape(value, enc, false)
+}
+
+// UnescapeStripFormatting is Unescape that also removes the formatting
+// sequences, \H\ and \N\ (highlighting) and the ones that start with a dot
+// such as \.br\ or \.sp2\, for when only the text of a value is wanted.
+func UnescapeStripFormatting(value string, enc Encoding) string {
+	return unescape(value, enc, true)
+}
+
+func unescape(value string, enc Encoding, stripFormatting bool) string {
+	if strings.IndexByte(value, enc.Escape) == -1 {
+		return value
+	}
+	var b strings.Builder
+	b.Grow(len(value))
+	for {
+		start := strings.IndexByte(value, enc.Escape)
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(value[start+1:], enc.Escape)
+		if end == -1 {
+			break
+		}
+		end += start + 1
+		b.WriteString(value[:start])
+		b.WriteString(unescapeSequence(value[start:end+1], value[start+1:end], enc, stripFormatting))
+		value = value[end+1:]
+	}
+	b.WriteString(value)
+	return b.String()
+}
+
+// unescapeSequence returns what the escape sequence with the given content
+// (without the surrounding escape characters) stands for.
+func unescapeSequence(sequence string, content string, enc Encoding, stripFormatting bool) string {
+	switch content {
+	case "F":
+		return string(enc.Field)
+	case "S":
+		return string(enc.Component)
+	case "T":
+		return string(enc.Subcomponent)
+	case "R":
+		return string(enc.Repetition)
+	case "E":
+		return string(enc.Escape)
+	case "P":
+		if enc.Truncation != 0 {
+			return string(enc.Truncation)
+		}
+		return sequence
+	case "H", "N":
+		if stripFormatting {
+			return ""
+		}
+		return sequence
+	}
+	if len(content) > 1 && content[0] == 'X' {
+		if decoded, err := hex.DecodeString(content[1:]); err == nil {
+			return string(decoded)
+		}
+	}
+	if stripFormatting && len(content) > 1 && content[0] == '.' {
+		return ""
+	}
+	return sequence
+}
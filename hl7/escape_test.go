@@ -0,0 +1,65 @@
+package hl7
+
+import "testing"
+
+func TestEscapeSequences(t *testing.T) {
+	expectDeepValue(t,
+		[]string{`\F\`, `\H\`, `\N\`, `\X0D0A\`, `\.br\`},
+		EscapeSequences(`2222 HOMES\F\TREET \H\IMPORTANT\N\ line\X0D0A\next\.br\`, DefaultSeparators),
+	)
+
+	// an unterminated escape is not a sequence
+	expectDeepValue(t, []string{`\E\`}, EscapeSequences(`C:\E\temp\dir`, DefaultSeparators))
+
+	expectDeepValue(t, []string(nil), EscapeSequences("EVERYWOMAN", DefaultSeparators))
+
+	// custom escape character
+	custom := DefaultSeparators
+	custom.Escape = '!'
+	expectDeepValue(t, []string{"!S!"}, EscapeSequences(`a!S!b\F\c`, custom))
+}
+
+func TestEscape(t *testing.T) {
+	expectValue(t, `a\F\b\S\c`, Escape("a|b^c", DefaultSeparators))
+	expectValue(t, `C:\E\temp\E\F\E\`, Escape(`C:\temp\F\`, DefaultSeparators))
+	expectValue(t, `a\T\b\R\c\X0D\\X0A\`, Escape("a&b~c\r\n", DefaultSeparators))
+	expectValue(t, "EVERYWOMAN", Escape("EVERYWOMAN", DefaultSeparators))
+
+	custom := Encoding{Field: '#', Component: '!', Repetition: '@', Escape: '$', Subcomponent: '%'}
+	expectValue(t, `a$F$b$E$c|^`, Escape("a#b$c|^", custom))
+
+	// escaping and unescaping round trips
+	for _, value := range []string{"a|b^c", `\F\`, "x&y~z\\", "line\r\nnext"} {
+		expectValue(t, value, Unescape(Escape(value, DefaultSeparators), DefaultSeparators))
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	expectValue(t, "2222 HOMES|TREET", Unescape(`2222 HOMES\F\TREET`, DefaultSeparators))
+	expectValue(t, `a^b&c~d\e`, Unescape(`a\S\b\T\c\R\d\E\e`, DefaultSeparators))
+	expectValue(t, "line\r\nnext", Unescape(`line\X0D0A\next`, DefaultSeparators))
+
+	// formatting, unknown and malformed sequences are kept
+	expectValue(t, `\H\IMPORTANT\N\ a\.br\b`, Unescape(`\H\IMPORTANT\N\ a\.br\b`, DefaultSeparators))
+	expectValue(t, `\Zfoo\ \XZZ\ \X\`, Unescape(`\Zfoo\ \XZZ\ \X\`, DefaultSeparators))
+	expectValue(t, `C:\temp`, Unescape(`C:\temp`, DefaultSeparators))
+	expectValue(t, `C:\\temp`, Unescape(`C:\E\\temp`, DefaultSeparators))
+
+	expectValue(t, "IMPORTANT ab", UnescapeStripFormatting(`\H\IMPORTANT\N\ a\.br\b`, DefaultSeparators))
+	expectValue(t, "a|b", UnescapeStripFormatting(`a\.sp2\\F\b`, DefaultSeparators))
+
+	// the characters of the message are used
+	custom := Encoding{Field: '#', Component: '!', Repetition: '@', Escape: '$', Subcomponent: '%'}
+	expectValue(t, "a#b!c\\F\\", Unescape(`a$F$b$S$c\F\`, custom))
+}
+
+func TestSplitEscaped(t *testing.T) {
+	expectDeepValue(t, []string{"A", "B", "", "C"}, splitEscaped("A^B^^C", '^', '\\'))
+	expectDeepValue(t, []string{"A\\S\\B", "C"}, splitEscaped("A\\S\\B^C", '^', '\\'))
+	// a separator in an escape sequence is data
+	expectDeepValue(t, []string{"A\\Zx^y\\B", "C"}, splitEscaped("A\\Zx^y\\B^C", '^', '\\'))
+	expectDeepValue(t, []string{"\\Z^\\", "\\Z^\\"}, splitEscaped("\\Z^\\^\\Z^\\", '^', '\\'))
+	// an escape character that is not closed is not a sequence
+	expectDeepValue(t, []string{"A\\B", "C"}, splitEscaped("A\\B^C", '^', '\\'))
+	expectDeepValue(t, []string{""}, splitEscaped("", '^', '\\'))
+}
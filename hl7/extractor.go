@@ -0,0 +1,47 @@
+package hl7
+
+// Extractor reads values from a single message, validating its header and
+// splitting it into segments once instead of on every call like AbstractHL7
+// does. It is safe for concurrent use: nothing about it changes after
+// NewExtractor, so many goroutines can Get from the same one.
+type Extractor struct {
+	message  string
+	sep      Encoding
+	segments []string
+}
+
+// NewExtractor validates the MSH header of message and returns an Extractor
+// for it.
+func NewExtractor(message string) (*Extractor, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	return &Extractor{
+		message:  message,
+		sep:      sep,
+		segments: splitByAnyOf(message, []string{"\r\n", "\r", "\n"}),
+	}, nil
+}
+
+// Encoding returns the encoding characters of the message.
+func (e *Extractor) Encoding() Encoding {
+	return e.sep
+}
+
+// Get returns the value at path, exactly as AbstractHL7 would.
+func (e *Extractor) Get(path HL7Path) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: err}
+	}
+	if path.hasWildcard() {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: errWildcardPath}
+	}
+	if path == (HL7Path{}) {
+		return e.message, nil
+	}
+	if path.Segment == "MSH" && path.Field == 1 {
+		return string(e.sep.Field), nil
+	}
+	return extractFromSegments(e.segments, path, e.sep), nil
+}
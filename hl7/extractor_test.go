@@ -0,0 +1,86 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExtractor(t *testing.T) {
+	e, err := NewExtractor(message)
+	expectValue(t, nil, err)
+	expectValue(t, DefaultSeparators, e.Encoding())
+
+	// every path reads the same as it does with AbstractHL7
+	for _, p := range []string{
+		"", "MSH", "MSH-1", "MSH-2", "MSH-9.2", "PID", "PID-3", "PID-3[2].5",
+		"PID-5[-1].2", "OBX[2]-5", "OBX[-1]", "ZZZ-2[2].2.3", "PID-40", "NTE-1",
+	} {
+		path, err := ParsePath(p)
+		expected, err1 := AbstractHL7(message, path)
+		got, err2 := e.Get(path)
+		expectValue(t, expected, got, err, err1, err2)
+	}
+
+	_, err = e.Get(HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: WildcardRepetition})
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+
+	_, err = e.Get(HL7Path{Segment: "PID", Field: 3})
+	expectError(t, err, "if Field is set, RepetitionIndex must be at least 1")
+
+	_, err = NewExtractor("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+// TestConcurrentExtraction shares parsed paths and an Extractor between
+// goroutines, run it with -race.
+func TestConcurrentExtraction(t *testing.T) {
+	paths := []HL7Path{
+		MustParsePath("MSH-10"),
+		MustParsePath("PID-3[2].1"),
+		MustParsePath("PID-5.1"),
+		MustParsePath("OBX[-1]-5"),
+	}
+	messages := make([]string, 8)
+	for i := range messages {
+		messages[i] = strings.Replace(message, "MSG00001", fmt.Sprintf("MSG%05d", i), 1)
+	}
+	shared, err := NewExtractor(message)
+	expectValue(t, nil, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(messages)*50)
+	for i, msg := range messages {
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				values, err := AbstractHL7Many(msg, paths)
+				if err == nil && (values[0] != fmt.Sprintf("MSG%05d", i) || values[1] != "123" || values[2] != "EVERYWOMAN" || values[3] != "79") {
+					err = fmt.Errorf("unexpected values %q", values)
+				}
+				if err == nil {
+					var e *Extractor
+					if e, err = NewExtractor(msg); err == nil {
+						_, err = e.Get(paths[2])
+					}
+				}
+				if err == nil {
+					var value string
+					if value, err = shared.Get(paths[1]); err == nil && value != "123" {
+						err = fmt.Errorf("unexpected value %q", value)
+					}
+				}
+				if err != nil {
+					errs <- err
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,52 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+// GetFieldWithReps returns the whole field the path points to, with every
+// repetition joined by the repetition separator and components and
+// subcomponents left intact. The repetition index, component and subcomponent
+// of the path are ignored.
+func GetFieldWithReps(message string, path HL7Path) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", err
+	}
+	if path.Field == 0 {
+		return "", errors.New("path must reference a field")
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+	segment, err := AbstractHL7(message, HL7Path{Segment: path.Segment, Where: path.Where, SegmentIndex: path.SegmentIndex})
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Split(segment, string(sep.Field))
+	if path.Segment == "MSH" {
+		fields = append(fields[:1], append([]string{string(sep.Field)}, fields[1:]...)...)
+	}
+	if path.Field >= len(fields) {
+		return "", nil
+	}
+	return fields[path.Field], nil
+}
+
+// RepetitionCount returns how many repetitions the field path points to has,
+// 0 when it is empty or missing. The repetition index, component and
+// subcomponent of the path are ignored. MSH-1 and MSH-2 are never split and
+// count as one.
+func RepetitionCount(message string, path HL7Path) (int, error) {
+	field, err := GetFieldWithReps(message, path)
+	if err != nil || field == "" {
+		return 0, err
+	}
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		return 1, nil
+	}
+	// the message was already validated by GetFieldWithReps
+	sep, _ := ParseEncoding(message)
+	return strings.Count(field, string(sep.Repetition)) + 1, nil
+}
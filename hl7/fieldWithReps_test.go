@@ -0,0 +1,64 @@
+package hl7
+
+import "testing"
+
+func TestGetFieldWithReps(t *testing.T) {
+	path, err1 := ParsePath("PID-3")
+	resp, err2 := GetFieldWithReps(message, path)
+	expectValue(t, "555-44-4444^^^^SSN~123^^^^MRN", resp, err1, err2)
+
+	// the repetition and component are ignored
+	path, err1 = ParsePath("PID-5[2].2")
+	resp, err2 = GetFieldWithReps(message, path)
+	expectValue(t, "EVERYWOMAN^EVE^E^^^^L~QUE^SUZY^^^^^N", resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-2")
+	resp, err2 = GetFieldWithReps(message, path)
+	expectValue(t, "^~\\&", resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-1")
+	resp, err2 = GetFieldWithReps(message, path)
+	expectValue(t, "|", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-40")
+	resp, err2 = GetFieldWithReps(message, path)
+	expectValue(t, "", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[3]-1")
+	resp, err2 = GetFieldWithReps(message, path)
+	expectValue(t, "", resp, err1, err2)
+
+	_, err := GetFieldWithReps(message, HL7Path{Segment: "PID", SegmentIndex: 1})
+	expectError(t, err, "path must reference a field")
+}
+
+func TestRepetitionCount(t *testing.T) {
+	for p, expected := range map[string]int{
+		"PID-3":       2,
+		"PID-3[2].1":  2,
+		"PID-5[*]":    2,
+		"PID-7":       1,
+		"PID-4":       0,
+		"PID-40":      0,
+		"EVN-1":       0,
+		"ZZZ[1]-2":    2,
+		"ZZZ[2]-2":    1,
+		"MSH-1":       1,
+		"MSH-2":       1,
+		"OBX[-1]-5.1": 1,
+	} {
+		path, err1 := ParsePath(p)
+		count, err2 := RepetitionCount(message, path)
+		expectValue(t, expected, count, err1, err2)
+	}
+
+	// an empty repetition still counts
+	count, err := RepetitionCount("MSH|^~\\&|HIS\rPID|||~~123", MustParsePath("PID-3"))
+	expectValue(t, 3, count, err)
+
+	_, err = RepetitionCount(message, MustParsePath("PID"))
+	expectError(t, err, "path must reference a field")
+
+	_, err = RepetitionCount("PID|1", MustParsePath("PID-3"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,47 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+// ExtractInGroup extracts path from the nth (1-based) group of the message,
+// where a group starts at a groupAnchor segment and runs up to the next one
+// or the end of the message. Segment indexes in path count from the start of
+// the group, so ExtractInGroup(message, "OBR", 2, OBX-5) is OBX-5 of the
+// first OBX in the 2nd order group. A missing group or value is an empty
+// string, the same as AbstractHL7.
+func ExtractInGroup(message string, groupAnchor string, groupIndex int, path HL7Path) (string, error) {
+	if _, err := parseSegmentNameOrError(groupAnchor); err != nil {
+		return "", err
+	}
+	if groupIndex < 1 {
+		return "", errors.New("group index must be at least 1")
+	}
+	if err := path.Validate(); err != nil {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: err}
+	}
+	if path == (HL7Path{}) {
+		return "", errors.New("path must reference a segment")
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", &ExtractError{Path: path, Stage: StageHeader, Err: err}
+	}
+
+	var group []string
+	groups := 0
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		if name == groupAnchor {
+			groups++
+			if groups > groupIndex {
+				break
+			}
+		}
+		if groups == groupIndex {
+			group = append(group, segment)
+		}
+	}
+	return extractFromSegments(group, path, sep), nil
+}
@@ -0,0 +1,56 @@
+package hl7
+
+import "testing"
+
+var orderGroups = "MSH|^~\\&|LAB|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.5\r" +
+	"PID|||123\r" +
+	"OBR|1||CBC\r" +
+	"OBX|1|NM|WBC||7.2\r" +
+	"OBR|2||BMP\r" +
+	"NTE|1||fasting\r" +
+	"OBX|1|NM|NA||140\r" +
+	"OBX|2|NM|K||4.1\r" +
+	"OBR|3||LIPID"
+
+func TestExtractInGroup(t *testing.T) {
+	path, err1 := ParsePath("OBX-5")
+	resp, err2 := ExtractInGroup(orderGroups, "OBR", 2, path)
+	expectValue(t, "140", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[2]-5")
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 2, path)
+	expectValue(t, "4.1", resp, err1, err2)
+
+	// the group ends at the next anchor
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 1, path)
+	expectValue(t, "", resp, err1, err2)
+
+	path, err1 = ParsePath("OBR-3")
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 3, path)
+	expectValue(t, "LIPID", resp, err1, err2)
+
+	path, err1 = ParsePath("NTE-3")
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 2, path)
+	expectValue(t, "fasting", resp, err1, err2)
+
+	// segments before the first anchor belong to no group
+	path, err1 = ParsePath("PID-3")
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 1, path)
+	expectValue(t, "", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX-5")
+	resp, err2 = ExtractInGroup(orderGroups, "OBR", 4, path)
+	expectValue(t, "", resp, err1, err2)
+
+	_, err := ExtractInGroup(orderGroups, "OBR", 0, path)
+	expectError(t, err, "group index must be at least 1")
+
+	_, err = ExtractInGroup(orderGroups, "OBR", 1, HL7Path{})
+	expectError(t, err, "path must reference a segment")
+
+	_, err = ExtractInGroup(orderGroups, "OBR", 1, HL7Path{Segment: "OBX", SegmentIndex: 1, Field: 5})
+	expectError(t, err, "if Field is set, RepetitionIndex must be at least 1")
+
+	_, err = ExtractInGroup("PID|1", "OBR", 1, path)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
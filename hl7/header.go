@@ -0,0 +1,97 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MessageControlID returns MSH-10, the ID the sender uniquely identifies the
+// message with and that ACKs echo back.
+func MessageControlID(message string) (string, error) {
+	return AbstractHL7(message, HL7Path{
+		Segment:         "MSH",
+		SegmentIndex:    1,
+		Field:           10,
+		RepetitionIndex: 1,
+	})
+}
+
+// mshRequiredFields are the MSH fields every message needs after the
+// encoding characters, see ValidateMSHRequiredFields.
+var mshRequiredFields = []struct {
+	field int
+	name  string
+}{
+	{9, "message type"},
+	{10, "control ID"},
+	{11, "processing ID"},
+	{12, "version"},
+}
+
+// ValidateMSHRequiredFields checks that MSH-9 (message type), MSH-10 (control
+// ID), MSH-11 (processing ID) and MSH-12 (version) are there and not empty,
+// which a truncated header fails even when ValidateMSH passes. The error
+// names every field that is missing, like "MSH is missing required fields:
+// MSH-10 (control ID), MSH-12 (version)", and matches ErrInvalidMessage, as
+// do the errors of ValidateMSH which is checked first.
+func ValidateMSHRequiredFields(message string) error {
+	if _, err := ValidateMSH(message); err != nil {
+		return err
+	}
+	var missing []string
+	for _, required := range mshRequiredFields {
+		path := HL7Path{Segment: "MSH", SegmentIndex: 1, Field: required.field, RepetitionIndex: 1}
+		// a field with repetitions is there if its first one is
+		if value, _ := AbstractHL7(message, path); value == "" {
+			missing = append(missing, fmt.Sprintf("%s (%s)", path, required.name))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	field := "field"
+	if len(missing) > 1 {
+		field = "fields"
+	}
+	return &kindError{ErrInvalidMessage, fmt.Errorf("MSH is missing required %s: %s", field, strings.Join(missing, ", "))}
+}
+
+// MessageType returns the components of MSH-9: the message code (ADT), the
+// trigger event (A01) and the message structure (ADT_A01), any of which may
+// be empty. A message that does not start with an MSH segment returns an error
+// matching ErrSegmentNotFound.
+func MessageType(message string) (messageCode, triggerEvent, structure string, err error) {
+	if !strings.HasPrefix(message, "MSH") {
+		return "", "", "", &kindError{ErrSegmentNotFound, errors.New("MSH segment not found")}
+	}
+	components := make([]string, 3)
+	for i := range components {
+		path := HL7Path{Segment: "MSH", SegmentIndex: 1, Field: 9, RepetitionIndex: 1, Component: i + 1}
+		if components[i], err = AbstractHL7(message, path); err != nil {
+			return "", "", "", err
+		}
+	}
+	return components[0], components[1], components[2], nil
+}
+
+// checkMessageType compares MSH-9 to the expected message type component by
+// component, only the components given in expected are compared.
+func checkMessageType(message string, expected string) error {
+	path := HL7Path{Segment: "MSH", SegmentIndex: 1, Field: 9, RepetitionIndex: 1}
+	actual, err := AbstractHL7(message, path)
+	if err != nil {
+		return err
+	}
+	for i, want := range strings.Split(expected, "^") {
+		path.Component = i + 1
+		got, err := AbstractHL7(message, path)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("unexpected message type %q, expected %q", actual, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package hl7
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMessageControlID(t *testing.T) {
+	id, err := MessageControlID(message)
+	expectValue(t, "MSG00001", id, err)
+
+	id, err = MessageControlID("MSH|^~\\&|HIS|RIH")
+	expectValue(t, "", id, err)
+
+	_, err = MessageControlID("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestValidateMSHRequiredFields(t *testing.T) {
+	expectValue(t, nil, ValidateMSHRequiredFields(message))
+	expectValue(t, nil, ValidateMSHRequiredFields(truncatedMessage))
+
+	// a header cut short after MSH-9
+	err := ValidateMSHRequiredFields("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01\rPID|1")
+	expectError(t, err, "MSH is missing required fields: MSH-10 (control ID), MSH-11 (processing ID), MSH-12 (version)")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+
+	err = ValidateMSHRequiredFields("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|")
+	expectError(t, err, "MSH is missing required field: MSH-12 (version)")
+	err = ValidateMSHRequiredFields("MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131|||MSG00001||2.5")
+	expectError(t, err, "MSH is missing required fields: MSH-9 (message type), MSH-11 (processing ID)")
+
+	// only the first repetition is looked at, and a component is enough
+	expectValue(t, nil, ValidateMSHRequiredFields("MSH|^~\\&|||||||^A01|1|P~T|2.5"))
+	err = ValidateMSHRequiredFields("MSH|^~\\&|||||||ADT|1|~P|2.5")
+	expectError(t, err, "MSH is missing required field: MSH-11 (processing ID)")
+
+	err = ValidateMSHRequiredFields("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	err = ValidateMSHRequiredFields("MSH|^~\\^|HIS")
+	expectError(t, err, "component and subcomponent separators are identical: both '^'")
+}
+
+func TestMessageType(t *testing.T) {
+	code, trigger, structure, err := MessageType(message)
+	expectValue(t, "ADT|A01|", code+"|"+trigger+"|"+structure, err)
+
+	code, trigger, structure, err = MessageType("MSH#!@$%#HIS#RIH#EKG#EKG#20060529090131##ORU!R01!ORU_R01#1")
+	expectValue(t, "ORU|R01|ORU_R01", code+"|"+trigger+"|"+structure, err)
+
+	code, trigger, structure, err = MessageType("MSH|^~\\&|HIS|RIH")
+	expectValue(t, "||", code+"|"+trigger+"|"+structure, err)
+
+	_, _, _, err = MessageType("PID|1")
+	expectError(t, err, "MSH segment not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+
+	_, _, _, err = MessageType("MSH||~\\&|HIS")
+	expectError(t, err, "missing component separator")
+}
+
+func TestAbstractHL7OptsExpectMessageType(t *testing.T) {
+	path, err1 := ParsePath("PID-5.1")
+	resp, err2 := AbstractHL7Opts(message, path, WithExpectMessageType("ADT^A01"))
+	expectValue(t, "EVERYWOMAN", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(message, path, WithExpectMessageType("ADT"))
+	expectValue(t, "EVERYWOMAN", resp, err1, err2)
+
+	_, err := AbstractHL7Opts(message, path, WithExpectMessageType("ADT^A08"))
+	expectError(t, err, `unexpected message type "ADT^A01", expected "ADT^A08"`)
+
+	_, err = AbstractHL7Opts(message, path, WithExpectMessageType("ORU^R01"))
+	expectError(t, err, `unexpected message type "ADT^A01", expected "ORU^R01"`)
+
+	// a structure that isn't in the message doesn't match
+	_, err = AbstractHL7Opts(message, path, WithExpectMessageType("ADT^A01^ADT_A01"))
+	expectError(t, err, `unexpected message type "ADT^A01", expected "ADT^A01^ADT_A01"`)
+
+	// the expected type always uses ^, whatever the message uses
+	custom := "MSH|*~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT*A01|MSG00001|P|2.5\rPID|||123"
+	path, err1 = ParsePath("PID-3")
+	resp, err2 = AbstractHL7Opts(custom, path, WithExpectMessageType("ADT^A01"))
+	expectValue(t, "123", resp, err1, err2)
+}
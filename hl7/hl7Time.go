@@ -0,0 +1,66 @@
+package hl7
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseHL7Time parses an HL7 DTM or TS value,
+// YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ], like 20060529090131 or
+// 200605290901-0500. Parts that are left out are the start of the part before
+// them, so 2006 is midnight on January 1st 2006. A value without an offset is
+// returned in UTC as it can't say which time zone it is in.
+func ParseHL7Time(value string) (time.Time, error) {
+	m := dateTimeExp.FindStringSubmatch(value)
+	if m == nil || !validDateTime(m[1], m[2], m[3], m[4], m[5], m[6]) {
+		return time.Time{}, fmt.Errorf("%q is not an HL7 date/time, expected YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]", value)
+	}
+	part := func(s string, def int) int {
+		if s == "" {
+			return def
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	// the fraction is tenths down to ten thousandths of a second
+	nanoseconds := 0
+	if m[7] != "" {
+		nanoseconds = part((m[7] + "000000000")[:9], 0)
+	}
+	location := time.UTC
+	if m[8] != "" {
+		hours, minutes := part(m[9], 0), part(m[10], 0)
+		if hours > 23 || minutes > 59 {
+			return time.Time{}, fmt.Errorf("%q has an invalid offset %s%s%s", value, m[8], m[9], m[10])
+		}
+		offset := hours*60*60 + minutes*60
+		if m[8] == "-" {
+			offset = -offset
+		}
+		location = time.FixedZone(m[8]+m[9]+m[10], offset)
+	}
+	return time.Date(part(m[1], 0), time.Month(part(m[2], 1)), part(m[3], 1), part(m[4], 0), part(m[5], 0), part(m[6], 0), nanoseconds, location), nil
+}
+
+// AbstractHL7Time works like AbstractHL7 but parses the value with
+// ParseHL7Time, like MSH-7 or PID-7. A path to a whole field reads its first
+// component, so a TS with a degree of precision, 20060529^D, works too. An
+// empty value is an error.
+func AbstractHL7Time(message string, path HL7Path) (time.Time, error) {
+	value, err := abstractHL7Required(message, path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if path.Component == 0 {
+		// the message was already validated by AbstractHL7
+		sep, _ := ParseEncoding(message)
+		value, _, _ = strings.Cut(value, string(sep.Component))
+	}
+	t, err := ParseHL7Time(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
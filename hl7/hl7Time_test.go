@@ -0,0 +1,61 @@
+package hl7
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHL7Time(t *testing.T) {
+	parsed, err := ParseHL7Time("20060529090131")
+	expectValue(t, time.Date(2006, 5, 29, 9, 1, 31, 0, time.UTC), parsed, err)
+
+	// every precision
+	for value, expected := range map[string]time.Time{
+		"2006":                time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+		"200605":              time.Date(2006, 5, 1, 0, 0, 0, 0, time.UTC),
+		"20060529":            time.Date(2006, 5, 29, 0, 0, 0, 0, time.UTC),
+		"2006052909":          time.Date(2006, 5, 29, 9, 0, 0, 0, time.UTC),
+		"200605290901":        time.Date(2006, 5, 29, 9, 1, 0, 0, time.UTC),
+		"20060529090131.5":    time.Date(2006, 5, 29, 9, 1, 31, 500000000, time.UTC),
+		"20060529090131.1234": time.Date(2006, 5, 29, 9, 1, 31, 123400000, time.UTC),
+		"20240229235959.0001": time.Date(2024, 2, 29, 23, 59, 59, 100000, time.UTC),
+	} {
+		parsed, err = ParseHL7Time(value)
+		expectValue(t, true, expected.Equal(parsed), err)
+		expectValue(t, time.UTC, parsed.Location())
+	}
+
+	// offsets
+	parsed, err = ParseHL7Time("200605290901-0500")
+	expectValue(t, true, time.Date(2006, 5, 29, 14, 1, 0, 0, time.UTC).Equal(parsed), err)
+	_, offset := parsed.Zone()
+	expectValue(t, -5*60*60, offset)
+	parsed, err = ParseHL7Time("20060529090131.25+0530")
+	expectValue(t, true, time.Date(2006, 5, 29, 3, 31, 31, 250000000, time.UTC).Equal(parsed), err)
+	expectValue(t, "+0530", parsed.Location().String())
+
+	for _, value := range []string{"", "06", "20061329", "20060230", "2006052924", "20060529090160", "20060529.5", "20060529090131.12345", "200605290901-05", "2006-05-29", "20060529 0901"} {
+		_, err = ParseHL7Time(value)
+		expectError(t, err, `"`+value+`" is not an HL7 date/time, expected YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]`)
+	}
+	_, err = ParseHL7Time("200605290901+2400")
+	expectError(t, err, `"200605290901+2400" has an invalid offset +2400`)
+}
+
+func TestAbstractHL7Time(t *testing.T) {
+	parsed, err := AbstractHL7Time(message, MustParsePath("MSH-7"))
+	expectValue(t, time.Date(2006, 5, 29, 9, 1, 31, 0, time.UTC), parsed, err)
+	parsed, err = AbstractHL7Time(message, MustParsePath("PID-7"))
+	expectValue(t, time.Date(1961, 6, 15, 0, 0, 0, 0, time.UTC), parsed, err)
+
+	// a TS with a degree of precision
+	parsed, err = AbstractHL7Time("MSH|^~\\&|HIS\rPID|||||||200605^L", MustParsePath("PID-7"))
+	expectValue(t, time.Date(2006, 5, 1, 0, 0, 0, 0, time.UTC), parsed, err)
+
+	_, err = AbstractHL7Time(message, MustParsePath("MSH-8"))
+	expectError(t, err, "MSH-8: value is empty")
+	_, err = AbstractHL7Time(message, MustParsePath("PID-8"))
+	expectError(t, err, `PID-8: "F" is not an HL7 date/time, expected YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]`)
+	_, err = AbstractHL7Time("PID|1", MustParsePath("PID-7"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
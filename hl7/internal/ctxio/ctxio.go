@@ -0,0 +1,61 @@
+// Package ctxio lets reads from a stream give up once a context is done. It
+// is shared by the message scanner of hl7 and the mllp Reader.
+package ctxio
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Reader makes reads from the stream it wraps give up once the context it
+// watches is done.
+type Reader struct {
+	r   io.Reader
+	ctx context.Context
+}
+
+// NewReader returns a Reader reading from r that watches no context yet.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+func (c *Reader) Read(p []byte) (int, error) {
+	if c.ctx != nil {
+		if err := c.ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+	return c.r.Read(p)
+}
+
+// readDeadliner is a stream whose blocked reads can be interrupted, like a
+// net.Conn.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// Watch gives up reads when ctx is done, until the returned function is
+// called. A stream with a read deadline has it set to interrupt a blocked
+// read, no goroutine outlives the call.
+func (c *Reader) Watch(ctx context.Context) func() {
+	c.ctx = ctx
+	d, ok := c.r.(readDeadliner)
+	if !ok {
+		return func() { c.ctx = nil }
+	}
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		// a deadline in the past wakes up a blocked read
+		d.SetReadDeadline(time.Unix(1, 0))
+		close(interrupted)
+	})
+	return func() {
+		c.ctx = nil
+		if !stop() {
+			// the deadline was set, clear it for whoever reads next
+			<-interrupted
+			d.SetReadDeadline(time.Time{})
+		}
+	}
+}
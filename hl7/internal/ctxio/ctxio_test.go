@@ -0,0 +1,55 @@
+package ctxio
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReaderWatch(t *testing.T) {
+	// a plain reader gives up at the next read
+	r := NewReader(strings.NewReader("MSH|"))
+	ctx, cancel := context.WithCancel(context.Background())
+	release := r.Watch(ctx)
+	cancel()
+	_, err := r.Read(make([]byte, 4))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// and reads again once released
+	release()
+	p := make([]byte, 4)
+	n, err := r.Read(p)
+	if err != nil || string(p[:n]) != "MSH|" {
+		t.Fatalf("expected MSH|, got %q, %v", p[:n], err)
+	}
+}
+
+func TestReaderWatchInterrupt(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	r := NewReader(server)
+
+	// a read blocked on a connection is woken up by the deadline, or
+	// doesn't start if the context is already done
+	ctx, cancel := context.WithCancel(context.Background())
+	release := r.Watch(ctx)
+	go cancel()
+	_, err := r.Read(make([]byte, 4))
+	if !errors.Is(err, os.ErrDeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the read to give up, got %v", err)
+	}
+	release()
+
+	// which is cleared for the next read
+	go client.Write([]byte("MSH|"))
+	p := make([]byte, 4)
+	n, err := r.Read(p)
+	if err != nil || string(p[:n]) != "MSH|" {
+		t.Fatalf("expected MSH|, got %q, %v", p[:n], err)
+	}
+}
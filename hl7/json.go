@@ -0,0 +1,380 @@
+package hl7
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToJSON converts a message to a JSON object keyed by segment name, like
+//
+//	{"MSH":{"1":"|","2":"^~\\&","3":"HIS",...},"PID":[{"1":"","2":"",...}]}
+//
+// MSH is an object as a message has one, every other segment is an array with
+// an object per occurrence so the shape does not depend on how often the
+// segment appears. Segments are in the order they first appear in.
+//
+// A segment object has a key per field, "1" for field 1, in order and
+// including empty fields. A field with several repetitions is an array of
+// them. A repetition without components is a string, otherwise an object
+// with a key per component, and the same goes for components with
+// subcomponents. A repetition that only has subcomponents is an object with
+// component "1" holding them, {"1":{"1":"A","2":"B"}} for A&B. MSH-1 and
+// MSH-2 hold the encoding characters as strings. Values are unescaped, see
+// Unescape.
+func ToJSON(message string) ([]byte, error) {
+	m, err := Parse(message)
+	if err != nil {
+		return nil, err
+	}
+	// group the occurrences of each segment, in order of first appearance
+	var names []string
+	occurrences := map[string][]Segment{}
+	for _, segment := range m.Segments {
+		if _, ok := occurrences[segment.Name]; !ok {
+			names = append(names, segment.Name)
+		}
+		occurrences[segment.Name] = append(occurrences[segment.Name], segment)
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeJSONString(&b, name)
+		b.WriteByte(':')
+		if name == "MSH" {
+			// a message has a single MSH, any other is not the header
+			m.writeSegmentJSON(&b, occurrences[name][0])
+			continue
+		}
+		b.WriteByte('[')
+		for j, segment := range occurrences[name] {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			m.writeSegmentJSON(&b, segment)
+		}
+		b.WriteByte(']')
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (m *Message) writeSegmentJSON(b *bytes.Buffer, segment Segment) {
+	b.WriteByte('{')
+	for i, field := range segment.Fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeJSONString(b, strconv.Itoa(i+1))
+		b.WriteByte(':')
+		if segment.Name == "MSH" && i < 2 {
+			// the encoding characters are not structured or escaped
+			var raw strings.Builder
+			m.encodeRepetition(&raw, field[0])
+			writeJSONString(b, raw.String())
+			continue
+		}
+		if len(field) == 1 {
+			m.writeRepetitionJSON(b, field[0])
+			continue
+		}
+		b.WriteByte('[')
+		for r, repetition := range field {
+			if r > 0 {
+				b.WriteByte(',')
+			}
+			m.writeRepetitionJSON(b, repetition)
+		}
+		b.WriteByte(']')
+	}
+	b.WriteByte('}')
+}
+
+func (m *Message) writeRepetitionJSON(b *bytes.Buffer, repetition Repetition) {
+	// a single component with subcomponents keeps the component level, or
+	// it would read back as components
+	if len(repetition) == 1 && len(repetition[0]) == 1 {
+		m.writeComponentJSON(b, repetition[0])
+		return
+	}
+	b.WriteByte('{')
+	for c, component := range repetition {
+		if c > 0 {
+			b.WriteByte(',')
+		}
+		writeJSONString(b, strconv.Itoa(c+1))
+		b.WriteByte(':')
+		m.writeComponentJSON(b, component)
+	}
+	b.WriteByte('}')
+}
+
+func (m *Message) writeComponentJSON(b *bytes.Buffer, component Component) {
+	if len(component) == 1 {
+		writeJSONString(b, Unescape(component[0], m.Encoding))
+		return
+	}
+	b.WriteByte('{')
+	for s, subcomponent := range component {
+		if s > 0 {
+			b.WriteByte(',')
+		}
+		writeJSONString(b, strconv.Itoa(s+1))
+		b.WriteByte(':')
+		writeJSONString(b, Unescape(subcomponent, m.Encoding))
+	}
+	b.WriteByte('}')
+}
+
+// FromJSON converts a JSON object shaped like the output of ToJSON back into a
+// message. The encoding characters are taken from MSH "1" and "2" and default
+// to |^~\&, values are escaped with them. MSH comes first, then the other
+// segments in the order of their keys, the occurrences of a segment one after
+// another. A segment can also be a single object instead of an array, and
+// fields and pieces that are left out are empty. Segments are terminated with
+// \r, except for the last one.
+//
+// Converting a message to JSON and back gives the same message, as long as
+// its segments are not interleaved (OBR, OBX, OBR, OBX comes back as OBR,
+// OBR, OBX, OBX) and it has no escape sequences other than those of the
+// encoding characters.
+func FromJSON(data []byte) (string, error) {
+	segments, err := decodeJSONSegments(data)
+	if err != nil {
+		return "", err
+	}
+	var msh json.RawMessage
+	for _, segment := range segments {
+		if segment.name == "MSH" {
+			msh = segment.value
+		}
+	}
+	if msh == nil {
+		return "", &kindError{ErrSegmentNotFound, errors.New("MSH segment not found")}
+	}
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(msh, &header); err != nil {
+		return "", fmt.Errorf("MSH: %w", err)
+	}
+	field, encodingCharacters := "|", `^~\&`
+	if raw, ok := header["1"]; ok {
+		if err := json.Unmarshal(raw, &field); err != nil || len(field) != 1 {
+			return "", errors.New("MSH-1 must be a single character")
+		}
+	}
+	if raw, ok := header["2"]; ok {
+		err := json.Unmarshal(raw, &encodingCharacters)
+		if err != nil || len(encodingCharacters) < 4 || len(encodingCharacters) > 5 {
+			return "", errors.New("MSH-2 must be 4 or 5 characters")
+		}
+	}
+	enc := Encoding{
+		Field:        field[0],
+		Component:    encodingCharacters[0],
+		Repetition:   encodingCharacters[1],
+		Escape:       encodingCharacters[2],
+		Subcomponent: encodingCharacters[3],
+	}
+	if len(encodingCharacters) == 5 {
+		enc.Truncation = encodingCharacters[4]
+	}
+
+	var b strings.Builder
+	b.WriteString("MSH" + field + encodingCharacters)
+	fields, err := jsonPieces(msh, enc.Field, func(raw json.RawMessage) (string, error) {
+		return jsonField(raw, enc)
+	})
+	if err != nil {
+		return "", fmt.Errorf("MSH: %w", err)
+	}
+	// MSH-1 and MSH-2 were written already
+	if _, rest, ok := strings.Cut(fields, string(enc.Field)); ok {
+		if _, rest, ok = strings.Cut(rest, string(enc.Field)); ok {
+			b.WriteString(field + rest)
+		}
+	}
+	for _, segment := range segments {
+		if segment.name == "MSH" {
+			continue
+		}
+		if _, err := parseSegmentNameOrError(segment.name); err != nil {
+			return "", fmt.Errorf("segment %q: %w", segment.name, err)
+		}
+		occurrences := []json.RawMessage{segment.value}
+		if jsonKind(segment.value) == '[' {
+			if err := json.Unmarshal(segment.value, &occurrences); err != nil {
+				return "", fmt.Errorf("%s: %w", segment.name, err)
+			}
+		}
+		for _, occurrence := range occurrences {
+			fields, err := jsonPieces(occurrence, enc.Field, func(raw json.RawMessage) (string, error) {
+				return jsonField(raw, enc)
+			})
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", segment.name, err)
+			}
+			b.WriteString("\r" + segment.name)
+			if fields != "" {
+				b.WriteString(field + fields)
+			}
+		}
+	}
+	message := b.String()
+	// make sure the encoding characters are usable
+	if _, err := ParseEncoding(message); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+type jsonSegment struct {
+	name  string
+	value json.RawMessage
+}
+
+// decodeJSONSegments reads the top level object keeping the order of its
+// keys, which encoding/json does not do for maps.
+func decodeJSONSegments(data []byte) ([]jsonSegment, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil, errors.New("invalid JSON message: expected an object of segments")
+	}
+	var segments []jsonSegment
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON message: %w", err)
+		}
+		segment := jsonSegment{name: token.(string)}
+		if err := dec.Decode(&segment.value); err != nil {
+			return nil, fmt.Errorf("invalid JSON message: %w", err)
+		}
+		segments = append(segments, segment)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	return segments, nil
+}
+
+// jsonKind returns the first character of a JSON value, telling strings,
+// arrays and objects apart.
+func jsonKind(raw json.RawMessage) byte {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
+
+// jsonPieces joins the values of an object keyed by 1-based position with
+// sep, each converted by piece. Positions that are left out are empty.
+func jsonPieces(raw json.RawMessage, sep byte, piece func(json.RawMessage) (string, error)) (string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return "", err
+	}
+	var pieces []string
+	for key, value := range object {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 1 {
+			return "", fmt.Errorf("invalid position %q", key)
+		}
+		pieces = padParts(pieces, i)
+		if pieces[i-1], err = piece(value); err != nil {
+			return "", fmt.Errorf("%d: %w", i, err)
+		}
+	}
+	return strings.Join(pieces, string(sep)), nil
+}
+
+// jsonField converts a field, a repetition or an array of them.
+func jsonField(raw json.RawMessage, enc Encoding) (string, error) {
+	if jsonKind(raw) != '[' {
+		return jsonRepetition(raw, enc)
+	}
+	var repetitions []json.RawMessage
+	if err := json.Unmarshal(raw, &repetitions); err != nil {
+		return "", err
+	}
+	encoded := make([]string, len(repetitions))
+	for i, repetition := range repetitions {
+		var err error
+		if encoded[i], err = jsonRepetition(repetition, enc); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(encoded, string(enc.Repetition)), nil
+}
+
+// jsonRepetition converts a repetition, a value or an object of components.
+func jsonRepetition(raw json.RawMessage, enc Encoding) (string, error) {
+	if jsonKind(raw) != '{' {
+		return jsonValue(raw, enc)
+	}
+	return jsonPieces(raw, enc.Component, func(raw json.RawMessage) (string, error) {
+		if jsonKind(raw) != '{' {
+			return jsonValue(raw, enc)
+		}
+		return jsonPieces(raw, enc.Subcomponent, func(raw json.RawMessage) (string, error) {
+			return jsonValue(raw, enc)
+		})
+	})
+}
+
+// jsonValue converts a string, or null for an empty value, escaping it.
+func jsonValue(raw json.RawMessage, enc Encoding) (string, error) {
+	var value *string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("expected a string, got %s", bytes.TrimSpace(raw))
+	}
+	if value == nil {
+		return "", nil
+	}
+	return Escape(*value, enc), nil
+}
+
+// writeJSONString writes s as a JSON string. Unlike encoding/json it does
+// not escape <, > and &, which are common in HL7 data, & being the default
+// subcomponent separator.
+func writeJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c == '\n':
+				b.WriteString(`\n`)
+			case c == '\r':
+				b.WriteString(`\r`)
+			case c == '\t':
+				b.WriteString(`\t`)
+			case c < 0x20:
+				fmt.Fprintf(b, `\u%04x`, c)
+			default:
+				b.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(`\ufffd`)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	b.WriteByte('"')
+}
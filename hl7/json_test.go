@@ -0,0 +1,74 @@
+package hl7
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	data, err := ToJSON(message)
+	expectValue(t, true, json.Valid(data), err)
+
+	// fields keep their order and the encoding characters are plain strings
+	expectValue(t, true, strings.HasPrefix(string(data), `{"MSH":{"1":"|","2":"^~\\&","3":"HIS","4":"RIH","5":"EKG","6":"EKG","7":"20060529090131","8":"","9":{"1":"ADT","2":"A01"},"10":"MSG00001","11":"P","12":"2.5"},"PID":[{`))
+	// a repeated segment is an array, so is a segment that appears once
+	expectValue(t, true, strings.Contains(string(data), `"OBX":[{"1":"1","2":"ST","3":{"1":"","2":"Body Height"},`))
+	expectValue(t, true, strings.Contains(string(data), `"PV1":[{"1":"","2":"I","3":{"1":"2000","2":"2012","3":"01"},`))
+	// repetitions are arrays and subcomponents nest in components
+	expectValue(t, true, strings.Contains(string(data), `"ZZZ":[{"1":"","2":["This is",{"1":"a","2":{"1":"custom","2":"segment","3":"with"},"3":{"1":"custom","2":"fields"}}]},{"1":"","2":"foo","3":"bar","4":"baz"}]}`))
+
+	var decoded map[string]any
+	expectValue(t, nil, json.Unmarshal(data, &decoded))
+	pid := decoded["PID"].([]any)[0].(map[string]any)
+	expectValue(t, "MRN", pid["3"].([]any)[1].(map[string]any)["5"])
+	expectValue(t, "19610615", pid["7"])
+}
+
+func TestToJSONEscapes(t *testing.T) {
+	// values are unescaped and encoded as JSON
+	data, err := ToJSON("MSH#!@$%#HIS#\"Q\"\rPID#1#A$F$B<C>&D\\E$X0D0A$")
+	expectValue(t, `{"MSH":{"1":"#","2":"!@$%","3":"HIS","4":"\"Q\""},"PID":[{"1":"1","2":"A#B<C>&D\\E\r\n"}]}`, string(data), err)
+
+	_, err = ToJSON("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestFromJSON(t *testing.T) {
+	// the sample comes back as it was
+	data, err1 := ToJSON(message)
+	msg, err2 := FromJSON(data)
+	expectValue(t, message, msg, err1, err2)
+
+	// subcomponents without components keep their level
+	data, err1 = ToJSON("MSH|^~\\&|HIS\rPID|1|A&B~C^D&E")
+	expectValue(t, `{"MSH":{"1":"|","2":"^~\\&","3":"HIS"},"PID":[{"1":"1","2":[{"1":{"1":"A","2":"B"}},{"1":"C","2":{"1":"D","2":"E"}}]}]}`, string(data), err1)
+	msg, err2 = FromJSON(data)
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A&B~C^D&E", msg, err1, err2)
+
+	// the encoding characters of MSH-1 and MSH-2 are used
+	custom := "MSH#!@$%#HIS\rPID#1#A$F$B"
+	data, err1 = ToJSON(custom)
+	msg, err2 = FromJSON(data)
+	expectValue(t, custom, msg, err1, err2)
+
+	// values are escaped, missing pieces are empty and single segments don't
+	// need an array
+	msg, err1 = FromJSON([]byte(`{"PID":{"3":[{"1":"1^2","5":"MRN"},"9"],"5":{"2":{"2":"b|c"}},"1":null},"MSH":{"3":"HIS"}}`))
+	expectValue(t, "MSH|^~\\&|HIS\rPID|||1\\S\\2^^^^MRN~9||^&b\\F\\c", msg, err1)
+
+	_, err1 = FromJSON([]byte(`{"PID":[{"1":"1"}]}`))
+	expectError(t, err1, "MSH segment not found")
+	_, err1 = FromJSON([]byte(`{"MSH":{"2":"^~"}}`))
+	expectError(t, err1, "MSH-2 must be 4 or 5 characters")
+	_, err1 = FromJSON([]byte(`{"MSH":{"2":"^^\\&"}}`))
+	expectValue(t, true, err1 != nil)
+	_, err1 = FromJSON([]byte(`{"MSH":{},"PID":[{"x":"1"}]}`))
+	expectError(t, err1, `PID: invalid position "x"`)
+	_, err1 = FromJSON([]byte(`{"MSH":{},"PID":[{"1":{"1":2}}]}`))
+	expectError(t, err1, "PID: 1: 1: expected a string, got 2")
+	_, err1 = FromJSON([]byte(`{"MSH":{},"pid":[]}`))
+	expectError(t, err1, `segment "pid": segment name must begin with an uppercase letter`)
+	_, err1 = FromJSON([]byte(`[]`))
+	expectError(t, err1, "invalid JSON message: expected an object of segments")
+}
@@ -0,0 +1,239 @@
+package hl7
+
+import "strings"
+
+// Message is a message parsed once into a tree of segments, fields,
+// repetitions, components and subcomponents, for when many values are read
+// from the same message. Get reads from the tree without going back to the
+// message text.
+type Message struct {
+	// Encoding holds the encoding characters declared in MSH-1 and MSH-2.
+	Encoding Encoding
+	Segments []Segment
+	// terminators holds what followed each segment in the original message,
+	// including any blank lines, so it can be put back together exactly.
+	terminators []string
+}
+
+// Segment is a parsed segment. Fields[0] is field 1, for MSH that is MSH-1,
+// the field separator, followed by MSH-2, the encoding characters, which is
+// never split by repetition.
+type Segment struct {
+	Name   string
+	Fields []Field
+}
+
+// Field holds the repetitions of a field, an empty field has a single empty
+// repetition.
+type Field []Repetition
+
+// Repetition holds the components of one repetition of a field.
+type Repetition []Component
+
+// Component holds the subcomponents of a component.
+type Component []string
+
+// Parse validates the MSH header of message and parses the whole message into
+// a Message.
+func Parse(message string) (*Message, error) {
+	enc, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{Encoding: enc}
+	lines, terminators := splitSegmentsKeepingTerminators(message)
+	for i, line := range lines {
+		// blank lines are not segments, keep them with the terminator of the
+		// segment before them
+		if line == "" && len(m.Segments) > 0 {
+			m.terminators[len(m.terminators)-1] += terminators[i]
+			continue
+		}
+		m.Segments = append(m.Segments, parseSegment(line, enc))
+		m.terminators = append(m.terminators, terminators[i])
+	}
+	return m, nil
+}
+
+func parseSegment(line string, enc Encoding) Segment {
+	fields := strings.Split(line, string(enc.Field))
+	segment := Segment{Name: fields[0]}
+	fields = fields[1:]
+	if segment.Name == "MSH" {
+		segment.Fields = append(segment.Fields, Field{{{string(enc.Field)}}})
+		if len(fields) > 0 {
+			segment.Fields = append(segment.Fields, Field{parseRepetition(fields[0], enc)})
+			fields = fields[1:]
+		}
+	}
+	for _, field := range fields {
+		segment.Fields = append(segment.Fields, parseField(field, enc))
+	}
+	return segment
+}
+
+func parseField(field string, enc Encoding) Field {
+	repetitions := strings.Split(field, string(enc.Repetition))
+	parsed := make(Field, len(repetitions))
+	for i, repetition := range repetitions {
+		parsed[i] = parseRepetition(repetition, enc)
+	}
+	return parsed
+}
+
+func parseRepetition(repetition string, enc Encoding) Repetition {
+	components := splitEscaped(repetition, enc.Component, enc.Escape)
+	parsed := make(Repetition, len(components))
+	for i, component := range components {
+		parsed[i] = splitEscaped(component, enc.Subcomponent, enc.Escape)
+	}
+	return parsed
+}
+
+// SegmentNames returns the name of every segment in message order.
+func (m *Message) SegmentNames() []string {
+	names := make([]string, len(m.Segments))
+	for i, segment := range m.Segments {
+		names[i] = segment.Name
+	}
+	return names
+}
+
+// Get returns the value at path the same way AbstractHL7 does for the message
+// that was parsed.
+func (m *Message) Get(path HL7Path) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: err}
+	}
+	if path.hasWildcard() {
+		return "", &ExtractError{Path: path, Stage: StagePath, Err: errWildcardPath}
+	}
+	if path == (HL7Path{}) {
+		return m.String(), nil
+	}
+	if path.Segment == "MSH" && path.Field == 1 {
+		return string(m.Encoding.Field), nil
+	}
+
+	segmentIndex := path.SegmentIndex
+	if segmentIndex < 0 {
+		total := 0
+		for _, segment := range m.Segments {
+			if m.selects(path, segment) {
+				total++
+			}
+		}
+		segmentIndex = resolveIndex(segmentIndex, total)
+	}
+	var b strings.Builder
+	count := 0
+	for _, segment := range m.Segments {
+		if !m.selects(path, segment) {
+			continue
+		}
+		count++
+		if count != segmentIndex {
+			continue
+		}
+		if path.Field == 0 {
+			m.encodeSegment(&b, segment)
+			return b.String(), nil
+		}
+		if path.Field > len(segment.Fields) {
+			return "", nil
+		}
+		field := segment.Fields[path.Field-1]
+		repetitionIndex := resolveIndex(path.RepetitionIndex, len(field))
+		if repetitionIndex < 1 || repetitionIndex > len(field) {
+			return "", nil
+		}
+		repetition := field[repetitionIndex-1]
+		if path.Component == 0 {
+			m.encodeRepetition(&b, repetition)
+			return b.String(), nil
+		}
+		if path.Component > len(repetition) {
+			return "", nil
+		}
+		component := repetition[path.Component-1]
+		if path.Subcomponent == 0 {
+			return strings.Join(component, string(m.Encoding.Subcomponent)), nil
+		}
+		if path.Subcomponent > len(component) {
+			return "", nil
+		}
+		return component[path.Subcomponent-1], nil
+	}
+	return "", nil
+}
+
+// selects reports whether path counts segment, see HL7Path.selectsSegment.
+func (m *Message) selects(path HL7Path, segment Segment) bool {
+	if segment.Name != path.Segment {
+		return false
+	}
+	if path.Where == (SegmentPredicate{}) {
+		return true
+	}
+	var b strings.Builder
+	m.encodeSegment(&b, segment)
+	return path.selectsSegment(b.String(), m.Encoding)
+}
+
+// String encodes the message back into HL7 using its encoding characters.
+// Segments keep the terminators they were parsed with, so an unmodified
+// message is byte for byte the one given to Parse. Segments added to the tree
+// are terminated like the first segment, or with \r.
+func (m *Message) String() string {
+	var b strings.Builder
+	m.encode(&b)
+	return b.String()
+}
+
+func (m *Message) encode(b *strings.Builder) {
+	terminator := "\r"
+	if len(m.terminators) > 0 && m.terminators[0] != "" {
+		terminator = m.terminators[0]
+	}
+	for i, segment := range m.Segments {
+		m.encodeSegment(b, segment)
+		last := i == len(m.Segments)-1
+		switch {
+		case i < len(m.terminators) && (m.terminators[i] != "" || last):
+			b.WriteString(m.terminators[i])
+		case !last:
+			b.WriteString(terminator)
+		}
+	}
+}
+
+func (m *Message) encodeSegment(b *strings.Builder, segment Segment) {
+	b.WriteString(segment.Name)
+	for i, field := range segment.Fields {
+		// MSH-1 is the field separator, there is no separator before it or
+		// before MSH-2
+		if segment.Name != "MSH" || i > 1 {
+			b.WriteByte(m.Encoding.Field)
+		}
+		for r, repetition := range field {
+			if r > 0 {
+				b.WriteByte(m.Encoding.Repetition)
+			}
+			m.encodeRepetition(b, repetition)
+		}
+	}
+}
+
+func (m *Message) encodeRepetition(b *strings.Builder, repetition Repetition) {
+	for c, component := range repetition {
+		if c > 0 {
+			b.WriteByte(m.Encoding.Component)
+		}
+		for s, subcomponent := range component {
+			if s > 0 {
+				b.WriteByte(m.Encoding.Subcomponent)
+			}
+			b.WriteString(subcomponent)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package hl7
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"strings"
+)
+
+// HashOption configures MessageHash.
+type HashOption func(*hashOptions)
+
+type hashOptions struct {
+	ignore  []HL7Path
+	newHash func() hash.Hash
+}
+
+// WithoutTimestamp leaves MSH-7, the time the message was created, out of
+// the hash.
+func WithoutTimestamp() HashOption {
+	return WithoutPaths(MustParsePath("MSH-7"))
+}
+
+// WithoutControlID leaves MSH-10, the control ID, out of the hash, so a
+// retransmission with a new control ID hashes the same.
+func WithoutControlID() HashOption {
+	return WithoutPaths(MustParsePath("MSH-10"))
+}
+
+// WithoutPaths leaves the values at paths out of the hash, for other fields
+// that change between retransmissions.
+func WithoutPaths(paths ...HL7Path) HashOption {
+	return func(o *hashOptions) {
+		o.ignore = append(o.ignore, paths...)
+	}
+}
+
+// WithHashFunc hashes with newHash instead of SHA-256.
+func WithHashFunc(newHash func() hash.Hash) HashOption {
+	return func(o *hashOptions) {
+		o.newHash = newHash
+	}
+}
+
+// MessageHash returns the hex encoded SHA-256 of message, for spotting
+// duplicates. Segment terminators, blank lines and a terminator at the end
+// don't change the hash, the content of the segments does. Use WithoutPaths,
+// or WithoutTimestamp and WithoutControlID, to leave out fields a
+// retransmission changes: their values are emptied before hashing, so a
+// message with and without the field hash the same.
+func MessageHash(message string, opts ...HashOption) (string, error) {
+	o := hashOptions{newHash: sha256.New}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if _, err := ValidateMSH(message); err != nil {
+		return "", err
+	}
+	normalized := strings.Join(segmentLines(message), "\r")
+	for _, path := range o.ignore {
+		value, err := AbstractHL7(normalized, path)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			continue
+		}
+		if normalized, err = SetHL7(normalized, path, ""); err != nil {
+			return "", err
+		}
+	}
+	h := o.newHash()
+	h.Write([]byte(normalized))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,48 @@
+package hl7
+
+import (
+	"crypto/md5"
+	"strings"
+	"testing"
+)
+
+func TestMessageHash(t *testing.T) {
+	hash, err := MessageHash(message)
+	expectValue(t, 64, len(hash), err)
+
+	// segment terminators don't matter, content does
+	for _, same := range []string{
+		strings.ReplaceAll(message, "\r", "\n"),
+		strings.ReplaceAll(message, "\r", "\r\n") + "\r\n",
+		strings.Replace(message, "\r", "\r\r", 1),
+	} {
+		other, err := MessageHash(same)
+		expectValue(t, hash, other, err)
+	}
+	retransmitted := strings.Replace(strings.Replace(message, "MSG00001", "MSG00002", 1), "20060529090131", "20060529090500", 1)
+	other, err := MessageHash(retransmitted)
+	expectValue(t, false, hash == other, err)
+	other, err = MessageHash(strings.Replace(message, "79", "80", 1))
+	expectValue(t, false, hash == other, err)
+
+	// volatile fields can be left out
+	hash, err = MessageHash(message, WithoutTimestamp(), WithoutControlID())
+	other, err1 := MessageHash(retransmitted, WithoutTimestamp(), WithoutControlID())
+	expectValue(t, hash, other, err, err1)
+	other, err1 = MessageHash(retransmitted, WithoutControlID())
+	expectValue(t, false, hash == other, err1)
+	hash, err = MessageHash("MSH|^~\\&|HIS\rPID|1|A", WithoutPaths(MustParsePath("PID-2")))
+	other, err1 = MessageHash("MSH|^~\\&|HIS\rPID|1|B", WithoutPaths(MustParsePath("PID-2")))
+	expectValue(t, hash, other, err, err1)
+	other, err1 = MessageHash("MSH|^~\\&|HIS\rPID|1|", WithoutPaths(MustParsePath("PID-2")))
+	expectValue(t, hash, other, err1)
+
+	// another hash
+	hash, err = MessageHash("MSH|^~\\&|HIS", WithHashFunc(md5.New))
+	expectValue(t, "00268f46b37cf35c6e8255ab6c31dc54", hash, err)
+
+	_, err = MessageHash(message, WithoutPaths(MustParsePath("PID-3[*]")))
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+	_, err = MessageHash("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,183 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/amaster507/goschemaless/hl7/internal/ctxio"
+	"github.com/amaster507/goschemaless/hl7/mllp"
+)
+
+// maxScanSegment is the longest segment a MessageScanner reads, enough for
+// an OBX carrying a large embedded document.
+const maxScanSegment = 256 << 20
+
+// MessageScanner reads messages one at a time from a stream, so a large
+// export or batch file never has to be held in memory. By default the stream
+// is split on MSH segments the way SplitBatch splits a string: batch headers
+// and trailers (FHS, BHS, BTS and FTS) are dropped, segments keep their
+// terminators and blank lines are dropped. With WithMLLPFraming every MLLP
+// frame is a message instead.
+//
+//	scanner := hl7.NewMessageScanner(f)
+//	for scanner.Scan() {
+//		process(scanner.Message())
+//	}
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type MessageScanner struct {
+	src      *ctxio.Reader
+	segments *bufio.Scanner
+	frames   *mllp.Reader
+	// pending is the MSH segment that ended the previous message
+	pending string
+	message string
+	err     error
+}
+
+// ScannerOption configures a MessageScanner.
+type ScannerOption func(*scannerOptions)
+
+type scannerOptions struct {
+	mllp bool
+}
+
+// WithMLLPFraming reads the stream as MLLP frames, see the mllp package,
+// each frame holding one message.
+func WithMLLPFraming() ScannerOption {
+	return func(o *scannerOptions) {
+		o.mllp = true
+	}
+}
+
+// NewMessageScanner returns a MessageScanner reading from r.
+func NewMessageScanner(r io.Reader, opts ...ScannerOption) *MessageScanner {
+	var o scannerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.mllp {
+		return &MessageScanner{frames: mllp.NewReader(r)}
+	}
+	src := ctxio.NewReader(r)
+	segments := bufio.NewScanner(src)
+	segments.Buffer(nil, maxScanSegment)
+	segments.Split(scanSegment)
+	return &MessageScanner{src: src, segments: segments}
+}
+
+// Scan reads the next message, which is then available from Message. It
+// returns false at the end of the stream or on an error, see Err.
+func (s *MessageScanner) Scan() bool {
+	return s.ScanContext(context.Background())
+}
+
+// ScanContext is like Scan but gives up once ctx is done, Err then returns
+// ctx.Err(). A read blocked on a stream with a read deadline, like a
+// net.Conn, is interrupted right away, any other stream is only checked
+// before each read from it. The message being read is lost and the scanner
+// stops, like it does on any other error.
+func (s *MessageScanner) ScanContext(ctx context.Context) bool {
+	s.message = ""
+	if s.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	if s.frames != nil {
+		message, err := s.frames.ReadMessageContext(ctx)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.err = err
+			}
+			return false
+		}
+		s.message = message
+		return true
+	}
+
+	release := s.src.Watch(ctx)
+	defer release()
+	var current strings.Builder
+	current.WriteString(s.pending)
+	s.pending = ""
+	for s.segments.Scan() {
+		line := s.segments.Text()
+		segment := strings.TrimRight(line, "\r\n")
+		if segment == "" {
+			continue
+		}
+		name := segment
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		switch {
+		case batchSegments[name]:
+			if current.Len() > 0 {
+				s.message = current.String()
+				return true
+			}
+			continue
+		case name == "MSH":
+			if current.Len() > 0 {
+				s.pending = line
+				s.message = current.String()
+				return true
+			}
+		case current.Len() == 0:
+			s.err = fmt.Errorf("unexpected segment %q before the first MSH", name)
+			return false
+		}
+		current.WriteString(line)
+	}
+	if err := s.segments.Err(); err != nil {
+		s.err = err
+		if ctx.Err() != nil {
+			s.err = ctx.Err()
+		}
+		return false
+	}
+	s.message = current.String()
+	return s.message != ""
+}
+
+// Message returns the message read by the last call to Scan.
+func (s *MessageScanner) Message() string {
+	return s.message
+}
+
+// Err returns the error that stopped Scan, or nil if it reached the end of
+// the stream.
+func (s *MessageScanner) Err() error {
+	return s.err
+}
+
+// scanSegment is a bufio.SplitFunc returning a segment along with its
+// terminator, \r, \n or \r\n.
+func scanSegment(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexAny(data, "\r\n")
+	if i == -1 {
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+	if data[i] == '\r' {
+		if i+1 == len(data) && !atEOF {
+			// the \n of a \r\n may be in the next read
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i+2], nil
+		}
+	}
+	return i + 1, data[:i+1], nil
+}
@@ -0,0 +1,96 @@
+package hl7
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/amaster507/goschemaless/hl7/mllp"
+)
+
+func scanAll(s *MessageScanner) []string {
+	messages := []string{}
+	for s.Scan() {
+		messages = append(messages, s.Message())
+	}
+	return messages
+}
+
+func TestMessageScanner(t *testing.T) {
+	first := withControlID("1") + "\r\n"
+	second := withControlID("2") + "\r"
+	for _, content := range []string{
+		"FHS|^~\\&|HIS\rBHS|^~\\&|HIS\r" + first + second + "BTS|2\rFTS|1\r",
+		"FHS|^~\\&\rBHS|^~\\&\r" + first + "BTS|1\rBHS|^~\\&\r" + second + "BTS|1\rFTS|2",
+		first + "\r\n\r\n" + second,
+	} {
+		// every message comes out the same as with SplitBatch
+		expected, err := SplitBatch(content)
+		expectDeepValue(t, []string{first, second}, expected, err)
+
+		s := NewMessageScanner(strings.NewReader(content))
+		expectDeepValue(t, expected, scanAll(s), s.Err())
+
+		// also when every read returns a single byte, splitting \r\n
+		s = NewMessageScanner(iotest.OneByteReader(strings.NewReader(content)))
+		expectDeepValue(t, expected, scanAll(s), s.Err())
+	}
+
+	s := NewMessageScanner(strings.NewReader(message))
+	expectDeepValue(t, []string{message}, scanAll(s), s.Err())
+
+	s = NewMessageScanner(strings.NewReader("FHS|^~\\&\rFTS|0\r"))
+	expectDeepValue(t, []string{}, scanAll(s), s.Err())
+
+	s = NewMessageScanner(strings.NewReader("PID|1\r" + first))
+	expectDeepValue(t, []string{}, scanAll(s))
+	expectError(t, s.Err(), `unexpected segment "PID" before the first MSH`)
+
+	s = NewMessageScanner(iotest.TimeoutReader(strings.NewReader(first + second)))
+	scanAll(s)
+	expectValue(t, true, errors.Is(s.Err(), iotest.ErrTimeout))
+}
+
+func TestMessageScannerMLLP(t *testing.T) {
+	var b strings.Builder
+	w := mllp.NewWriter(&b)
+	expectValue(t, nil, w.WriteMessage(message))
+	expectValue(t, nil, w.WriteMessage(withControlID("2")))
+
+	s := NewMessageScanner(iotest.HalfReader(strings.NewReader(b.String())), WithMLLPFraming())
+	expectDeepValue(t, []string{message, withControlID("2")}, scanAll(s), s.Err())
+
+	s = NewMessageScanner(strings.NewReader(b.String()[:20]), WithMLLPFraming())
+	expectDeepValue(t, []string{}, scanAll(s))
+	expectValue(t, mllp.ErrTruncatedFrame, s.Err())
+}
+
+func TestMessageScannerContext(t *testing.T) {
+	s := NewMessageScanner(strings.NewReader(message))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expectValue(t, false, s.ScanContext(ctx))
+	expectValue(t, context.Canceled, s.Err())
+	// the scanner stays stopped
+	expectValue(t, false, s.Scan())
+
+	// a read blocked in the middle of a message gives up at the deadline
+	for partial, opts := range map[string][]ScannerOption{
+		"MSH|^~\\&|HIS\rPID|1":     nil,
+		"\x0bMSH|^~\\&|HIS\rPID|1": {WithMLLPFraming()},
+	} {
+		client, server := net.Pipe()
+		s = NewMessageScanner(server, opts...)
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		go client.Write([]byte(partial))
+		expectValue(t, false, s.ScanContext(ctx))
+		expectValue(t, context.DeadlineExceeded, s.Err())
+		cancel()
+		client.Close()
+		server.Close()
+	}
+}
@@ -0,0 +1,163 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MessageSpec describes the segments a message type is made of, in the order
+// they must appear.
+type MessageSpec struct {
+	// MessageType is the MSH-9 the spec is for, e.g. "ADT^A01". When set the
+	// message must match it, compared like WithExpectMessageType does.
+	MessageType string
+	Segments    []SegmentSpec
+}
+
+// SegmentSpec is a segment of a MessageSpec and how many times it may appear
+// in a row.
+type SegmentSpec struct {
+	Name string
+	// Min is the minimum number of occurrences, anything above 0 makes the
+	// segment required.
+	Min int
+	// Max is the maximum number of occurrences. If omitted it defaults to 1,
+	// use Unbounded (-1) to allow any number.
+	Max int
+}
+
+// max returns the maximum number of occurrences, applying the default.
+func (s SegmentSpec) max() int {
+	if s.Max == 0 {
+		return 1
+	}
+	return s.Max
+}
+
+// ValidateMessage checks that the segments of message appear in the order
+// and number spec lists, and returns the first violation found, with the
+// position of the offending segment, as an error matching ErrSpecViolation.
+// A message declaring a truncation character in MSH-2 along with a version
+// before 2.7 in MSH-12 is invalid and returns an error matching
+// ErrInvalidMessage.
+// Segments the spec doesn't list, including Z segments, may appear
+// anywhere. Repeating groups of segments are not modeled yet, a segment
+// listed once can only appear in one run.
+func ValidateMessage(message string, spec MessageSpec) error {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return err
+	}
+	if sep.Truncation != 0 && !allowsTruncation(message) {
+		version, _ := MessageVersion(message)
+		return &kindError{ErrInvalidMessage, fmt.Errorf("truncation character %c requires HL7 %s or later, the message is %s", sep.Truncation, Version27, version)}
+	}
+	if spec.MessageType != "" {
+		if err := checkMessageType(message, spec.MessageType); err != nil {
+			return &kindError{ErrSpecViolation, err}
+		}
+	}
+	listed := map[string]bool{}
+	for _, s := range spec.Segments {
+		listed[s.Name] = true
+	}
+
+	// pos is the spec entry the last segment matched and count how many
+	// segments have matched it
+	pos, count := 0, 0
+	for i, segment := range segmentLines(message) {
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		if !listed[name] {
+			continue
+		}
+		violation := func(format string, args ...any) error {
+			return &kindError{ErrSpecViolation, fmt.Errorf("segment %d (%s): %s", i+1, name, fmt.Sprintf(format, args...))}
+		}
+		next := pos
+		for next < len(spec.Segments) && spec.Segments[next].Name != name {
+			next++
+		}
+		if next == len(spec.Segments) {
+			return violation("%s must come before %s", name, spec.Segments[pos].Name)
+		}
+		if next == pos {
+			count++
+			if limit := spec.Segments[pos].max(); limit != Unbounded && count > limit {
+				return violation("at most %d %s allowed", limit, name)
+			}
+			continue
+		}
+		// every entry skipped over must have been optional
+		for k := pos; k < next; k++ {
+			seen := 0
+			if k == pos {
+				seen = count
+			}
+			if seen < spec.Segments[k].Min {
+				return violation("required segment %s is missing before %s", spec.Segments[k].Name, name)
+			}
+		}
+		pos, count = next, 1
+	}
+	for k := pos; k < len(spec.Segments); k++ {
+		seen := 0
+		if k == pos {
+			seen = count
+		}
+		if seen < spec.Segments[k].Min {
+			return &kindError{ErrSpecViolation, fmt.Errorf("required segment %s is missing", spec.Segments[k].Name)}
+		}
+	}
+	return nil
+}
+
+// adtSegments are the leading segments shared by the ADT events.
+var adtSegments = []SegmentSpec{
+	{Name: "MSH", Min: 1},
+	{Name: "SFT", Max: Unbounded},
+	{Name: "EVN", Min: 1},
+	{Name: "PID", Min: 1},
+	{Name: "PD1"},
+	{Name: "NK1", Max: Unbounded},
+	{Name: "PV1", Min: 1},
+	{Name: "PV2"},
+}
+
+var (
+	specsMu sync.RWMutex
+	specs   = map[string]MessageSpec{
+		"ADT^A01": {MessageType: "ADT^A01", Segments: adtSegments},
+		"ADT^A03": {MessageType: "ADT^A03", Segments: adtSegments},
+		"ADT^A04": {MessageType: "ADT^A04", Segments: adtSegments},
+		"ADT^A08": {MessageType: "ADT^A08", Segments: adtSegments},
+	}
+)
+
+// RegisterMessageSpec makes spec available from LookupMessageSpec under its
+// MessageType, replacing any spec registered for it before, including the
+// built-in ADT^A01, ADT^A03, ADT^A04 and ADT^A08 specs.
+func RegisterMessageSpec(spec MessageSpec) error {
+	if spec.MessageType == "" {
+		return errors.New("spec has no message type to register it under")
+	}
+	for _, s := range spec.Segments {
+		if _, err := parseSegmentNameOrError(s.Name); err != nil {
+			return fmt.Errorf("segment %q: %w", s.Name, err)
+		}
+	}
+	specsMu.Lock()
+	defer specsMu.Unlock()
+	specs[spec.MessageType] = spec
+	return nil
+}
+
+// LookupMessageSpec returns the spec registered for a message type, e.g.
+// "ADT^A01".
+func LookupMessageSpec(messageType string) (MessageSpec, bool) {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+	spec, ok := specs[messageType]
+	return spec, ok
+}
@@ -0,0 +1,55 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const adtA01 = "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rEVN|A01|20060529090131\rPID|1||123^^^^MRN\rNK1|1|DOE^JOHN\rNK1|2|DOE^JILL\rPV1|1|I\rZPI|custom\rOBX|1|ST|^Note||x"
+
+func TestValidateMessage(t *testing.T) {
+	spec, ok := LookupMessageSpec("ADT^A01")
+	expectValue(t, true, ok)
+	expectValue(t, nil, ValidateMessage(adtA01, spec))
+
+	// optional segments can be left out, unlisted ones appear anywhere
+	expectValue(t, nil, ValidateMessage(strings.Replace(adtA01, "\rNK1|1|DOE^JOHN\rNK1|2|DOE^JILL", "\rZZZ|1", 1), spec))
+
+	for msg, expected := range map[string]string{
+		message: "segment 2 (PID): required segment EVN is missing before PID",
+		strings.Replace(adtA01, "\rPV1|1|I", "", 1):                   "required segment PV1 is missing",
+		strings.Replace(adtA01, "\rPV1|1|I", "\rPV1|1|I\rPV1|2", 1):   "segment 7 (PV1): at most 1 PV1 allowed",
+		strings.Replace(adtA01, "\rPV1|1|I", "\rPV1|1|I\rEVN|A01", 1): "segment 7 (EVN): EVN must come before PV1",
+		strings.Replace(adtA01, "ADT^A01", "ADT^A08", 1):              `unexpected message type "ADT^A08", expected "ADT^A01"`,
+	} {
+		err := ValidateMessage(msg, spec)
+		expectError(t, err, expected)
+		expectValue(t, true, errors.Is(err, ErrSpecViolation))
+	}
+
+	err := ValidateMessage("PID|1", spec)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestRegisterMessageSpec(t *testing.T) {
+	spec := MessageSpec{MessageType: "ORU^R01", Segments: []SegmentSpec{
+		{Name: "MSH", Min: 1},
+		{Name: "PID", Min: 1},
+		{Name: "OBR", Min: 1},
+		{Name: "OBX", Min: 1, Max: Unbounded},
+	}}
+	expectValue(t, nil, RegisterMessageSpec(spec))
+	registered, ok := LookupMessageSpec("ORU^R01")
+	expectValue(t, true, ok)
+
+	msg := "MSH|^~\\&|LAB||||20240101||ORU^R01|1|P|2.5\rPID|1\rOBR|1\rOBX|1\rOBX|2\rOBX|3"
+	expectValue(t, nil, ValidateMessage(msg, registered))
+	expectError(t, ValidateMessage(strings.Replace(msg, "\rOBR|1", "", 1), registered), "segment 3 (OBX): required segment OBR is missing before OBX")
+
+	_, ok = LookupMessageSpec("ORU^R30")
+	expectValue(t, false, ok)
+
+	expectError(t, RegisterMessageSpec(MessageSpec{}), "spec has no message type to register it under")
+	expectError(t, RegisterMessageSpec(MessageSpec{MessageType: "X", Segments: []SegmentSpec{{Name: "pid"}}}), `segment "pid": segment name must begin with an uppercase letter`)
+}
@@ -0,0 +1,67 @@
+package hl7
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	m, err := Parse(message)
+	expectValue(t, DefaultSeparators, m.Encoding, err)
+	expectDeepValue(t, []string{"MSH", "PID", "PV1", "OBX", "OBX", "ZZZ", "ZZZ"}, m.SegmentNames())
+
+	expectDeepValue(t, Field{{{"555-44-4444"}, {""}, {""}, {""}, {"SSN"}}, {{"123"}, {""}, {""}, {""}, {"MRN"}}}, m.Segments[1].Fields[2])
+	// MSH-1 and MSH-2 are the first two fields of MSH
+	expectDeepValue(t, Field{{{"|"}}}, m.Segments[0].Fields[0])
+	expectDeepValue(t, Field{{{""}, {"~\\", ""}}}, m.Segments[0].Fields[1])
+	expectDeepValue(t, Field{{{"HIS"}}}, m.Segments[0].Fields[2])
+
+	_, err = Parse("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+
+	// blank lines are not segments
+	m, err = Parse("MSH|^~\\&|HIS\r\rPID|1\n\n")
+	expectDeepValue(t, []string{"MSH", "PID"}, m.SegmentNames(), err)
+}
+
+func TestMessageGet(t *testing.T) {
+	m, err := Parse(message)
+	expectValue(t, nil, err)
+
+	// every leaf reads the same as it does with AbstractHL7
+	err = walkLeaves(message, func(path HL7Path, value string) {
+		got, err := m.Get(path)
+		expectValue(t, value, got, err)
+	})
+	expectValue(t, nil, err)
+
+	for _, p := range []string{
+		"", "MSH", "MSH-1", "MSH-2", "MSH-2.1", "MSH-9", "PID", "PID-3", "PID-3[2]",
+		"PID-5[2].2", "PV1-3.2", "OBX[2]", "OBX[2]-5", "ZZZ-2[2].2", "ZZZ-2[2].2.3",
+		"PID-40", "PID-3[3]", "PV1-2.2", "PV1-2.1.2", "OBX[3]-1", "NTE-1",
+		"PID-3[-1].5", "PID-3[-3]", "OBX[-1]-5", "OBX[-3]", "ZZZ[-2]-2[-2]",
+	} {
+		path, err := ParsePath(p)
+		expected, err1 := AbstractHL7(message, path)
+		got, err2 := m.Get(path)
+		expectValue(t, expected, got, err, err1, err2)
+	}
+
+	_, err = m.Get(HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: WildcardRepetition})
+	expectError(t, err, "wildcard paths can only be used with AbstractHL7All")
+}
+
+func TestMessageString(t *testing.T) {
+	for _, msg := range []string{
+		message,
+		message + "\r",
+		"MSH|^~\\&|HIS\r\nPID|1\nPV1|1\r\rOBX|1||\r\n\r\n",
+		"MSH#!@$%#HIS#A!B%C@D\rPID#1",
+	} {
+		m, err := Parse(msg)
+		expectValue(t, msg, m.String(), err)
+	}
+
+	// edits to the tree are encoded
+	m, err := Parse("MSH|^~\\&|HIS\nPID|1")
+	m.Segments[1].Fields[0] = Field{{{"2"}}, {{"A", "B"}, {"C"}}}
+	m.Segments = append(m.Segments, Segment{Name: "PV1", Fields: []Field{{{{"1"}}}}})
+	expectValue(t, "MSH|^~\\&|HIS\nPID|2~A&B^C\nPV1|1", m.String(), err)
+}
@@ -0,0 +1,69 @@
+package hl7
+
+import "strings"
+
+// Metrics describes how much of a message an extraction had to look at.
+type Metrics struct {
+	// SegmentsScanned is the number of segments looked at up to and
+	// including the target, or every segment if the target is missing.
+	SegmentsScanned int
+	// BytesProcessed is the number of bytes of the message up to and
+	// including the target segment and its terminator.
+	BytesProcessed int
+}
+
+// AbstractHL7Metrics works like AbstractHL7 and also reports Metrics about
+// the extraction. Measuring is done separately from the extraction itself so
+// AbstractHL7 pays nothing for it, use this variant only when the metrics
+// are wanted. A negative segment index is resolved against the message first,
+// like AbstractHL7 does, the metrics count the segments up to the one it
+// resolves to.
+func AbstractHL7Metrics(message string, path HL7Path) (string, Metrics, error) {
+	value, err := AbstractHL7(message, path)
+	if err != nil {
+		return "", Metrics{}, err
+	}
+	return value, measureExtraction(message, path), nil
+}
+
+func measureExtraction(message string, path HL7Path) Metrics {
+	m := Metrics{}
+	// the whole message is returned without being read
+	if path == (HL7Path{}) {
+		return m
+	}
+	// the extraction succeeded, so the header is valid
+	sep, _ := ParseEncoding(message)
+	index := path.SegmentIndex
+	if index < 0 {
+		index = resolveIndex(index, countSelectedSegments(segmentLines(message), path, sep))
+	}
+	count := 0
+	for len(message) > 0 {
+		end := strings.IndexAny(message, "\r\n")
+		line := message
+		if end == -1 {
+			message = ""
+		} else {
+			// count \r\n as a single terminator
+			next := end + 1
+			if message[end] == '\r' && next < len(message) && message[next] == '\n' {
+				next++
+			}
+			line, message = message[:end], message[next:]
+			m.BytesProcessed += next - end
+		}
+		m.BytesProcessed += len(line)
+		if line == "" {
+			continue
+		}
+		m.SegmentsScanned++
+		if path.selectsSegment(line, sep) {
+			count++
+			if count == index {
+				break
+			}
+		}
+	}
+	return m
+}
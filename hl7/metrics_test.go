@@ -0,0 +1,42 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7Metrics(t *testing.T) {
+	path, err1 := ParsePath("PV1-2")
+	resp, metrics, err2 := AbstractHL7Metrics(message, path)
+	expectValue(t, "I", resp, err1, err2)
+	// MSH, PID and PV1 with their terminators
+	expectValue(t, Metrics{SegmentsScanned: 3, BytesProcessed: 63 + 1 + 171 + 1 + 63 + 1}, metrics)
+
+	path, err1 = ParsePath("MSH-9")
+	_, metrics, err2 = AbstractHL7Metrics(message, path)
+	expectValue(t, Metrics{SegmentsScanned: 1, BytesProcessed: 64}, metrics, err1, err2)
+
+	// a negative index counts from the end, OBX[-2] is the first OBX
+	obx := "OBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F"
+	path, err1 = ParsePath("OBX[-2]-5")
+	resp, metrics, err2 = AbstractHL7Metrics(message, path)
+	expectValue(t, "1.80", resp, err1, err2)
+	expectValue(t, Metrics{SegmentsScanned: 4, BytesProcessed: 63 + 1 + 171 + 1 + 63 + 1 + len(obx) + 1}, metrics)
+	path, err1 = ParsePath("ZZZ[-1]")
+	_, metrics, err2 = AbstractHL7Metrics(message, path)
+	expectValue(t, Metrics{SegmentsScanned: 7, BytesProcessed: len(message)}, metrics, err1, err2)
+	path, err1 = ParsePath("OBX[-3]-5")
+	_, metrics, err2 = AbstractHL7Metrics(message, path)
+	expectValue(t, Metrics{SegmentsScanned: 7, BytesProcessed: len(message)}, metrics, err1, err2)
+
+	// a missing segment means every segment was scanned
+	path, err1 = ParsePath("EVN-1")
+	_, metrics, err2 = AbstractHL7Metrics(message, path)
+	expectValue(t, Metrics{SegmentsScanned: 7, BytesProcessed: len(message)}, metrics, err1, err2)
+
+	_, metrics, err2 = AbstractHL7Metrics("MSH|^~\\&|HIS\r\n\r\nPID|1\r\n", HL7Path{Segment: "PID", SegmentIndex: 1})
+	expectValue(t, Metrics{SegmentsScanned: 2, BytesProcessed: 23}, metrics, err2)
+
+	_, metrics, err2 = AbstractHL7Metrics(message, HL7Path{})
+	expectValue(t, Metrics{}, metrics, err2)
+
+	_, _, err := AbstractHL7Metrics("PID|1", path)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
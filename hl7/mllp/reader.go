@@ -0,0 +1,108 @@
+// Package mllp reads and writes HL7 messages framed with the Minimal Lower
+// Layer Protocol, the framing HL7 v2 interfaces use over TCP. Each message is
+// sent as a start block (0x0B), the message, an end block (0x1C) and a
+// carriage return (0x0D).
+package mllp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/amaster507/goschemaless/hl7/internal/ctxio"
+)
+
+// The block characters that frame a message.
+const (
+	StartBlock     byte = 0x0B
+	EndBlock       byte = 0x1C
+	CarriageReturn byte = 0x0D
+)
+
+var (
+	// ErrTruncatedFrame is returned when the stream ends in the middle of a
+	// frame.
+	ErrTruncatedFrame = errors.New("truncated frame: stream ended before the end of the frame")
+	// ErrInvalidFrame is returned for a frame that does not follow the
+	// protocol, the error says how.
+	ErrInvalidFrame = errors.New("invalid frame")
+)
+
+// Reader reads framed messages from a stream such as a net.Conn.
+type Reader struct {
+	r   *bufio.Reader
+	src *ctxio.Reader
+}
+
+// NewReader returns a Reader that reads frames from r.
+func NewReader(r io.Reader) *Reader {
+	src := ctxio.NewReader(r)
+	return &Reader{r: bufio.NewReader(src), src: src}
+}
+
+// ReadMessageContext is like ReadMessage but gives up with ctx.Err() once ctx
+// is done. A read blocked on a stream with a read deadline, like a net.Conn,
+// is interrupted right away, any other stream is only checked before each
+// read from it. Giving up in the middle of a frame loses the frame, the
+// Reader should not be used after that.
+func (r *Reader) ReadMessageContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	release := r.src.Watch(ctx)
+	msg, err := r.ReadMessage()
+	release()
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return msg, err
+}
+
+// ReadMessage reads the next frame and returns the message inside it without
+// the block characters. Line breaks between frames are skipped. At the end of
+// the stream it returns io.EOF if the stream ended between frames, or
+// ErrTruncatedFrame if it ended in the middle of one.
+func (r *Reader) ReadMessage() (string, error) {
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == StartBlock {
+			break
+		}
+		// some senders end every frame with an extra line break
+		if b != '\r' && b != '\n' {
+			return "", fmt.Errorf("%w: unexpected byte 0x%02X before the start block", ErrInvalidFrame, b)
+		}
+	}
+
+	payload, err := r.r.ReadBytes(EndBlock)
+	if err != nil {
+		return "", unexpectedEOF(err)
+	}
+	payload = payload[:len(payload)-1]
+	if bytes.IndexByte(payload, StartBlock) != -1 {
+		return "", fmt.Errorf("%w: start block inside a frame", ErrInvalidFrame)
+	}
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return "", unexpectedEOF(err)
+	}
+	if b != CarriageReturn {
+		return "", fmt.Errorf("%w: end block followed by 0x%02X instead of a carriage return", ErrInvalidFrame, b)
+	}
+	return string(payload), nil
+}
+
+// unexpectedEOF reports the end of the stream inside a frame as a truncated
+// frame.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return ErrTruncatedFrame
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package mllp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const message = "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||555-44-4444"
+
+func frame(message string) string {
+	return "\x0b" + message + "\x1c\r"
+}
+
+func expectValue(t *testing.T, expected any, received any, errors ...error) {
+	t.Helper()
+	for _, err := range errors {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if received != expected {
+		t.Errorf("\nExpected: %+v\nReceived: %+v", expected, received)
+	}
+}
+
+func expectError(t *testing.T, err error, expectedError string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error: %s but received none", expectedError)
+	}
+	if err.Error() != expectedError {
+		t.Errorf("\nExpected error: %s\nReceived error: %s", expectedError, err.Error())
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	second := "MSH|^~\\&|LAB|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.5\r"
+	r := NewReader(strings.NewReader(frame(message) + frame(second) + "\r\n" + frame("")))
+
+	msg, err := r.ReadMessage()
+	expectValue(t, message, msg, err)
+	msg, err = r.ReadMessage()
+	expectValue(t, second, msg, err)
+	msg, err = r.ReadMessage()
+	expectValue(t, "", msg, err)
+
+	_, err = r.ReadMessage()
+	expectValue(t, io.EOF, err)
+}
+
+func TestReadMessageInvalid(t *testing.T) {
+	for stream, expected := range map[string]string{
+		"\x0bMSH|^~\\&":              "truncated frame: stream ended before the end of the frame",
+		"\x0bMSH|^~\\&\x1c":          "truncated frame: stream ended before the end of the frame",
+		"MSH|^~\\&\x1c\r":            "invalid frame: unexpected byte 0x4D before the start block",
+		"\x0bMSH\x0bMSH|^~\\&\x1c\r": "invalid frame: start block inside a frame",
+		"\x0bMSH|^~\\&\x1c\n":        "invalid frame: end block followed by 0x0A instead of a carriage return",
+	} {
+		_, err := NewReader(strings.NewReader(stream)).ReadMessage()
+		expectError(t, err, expected)
+	}
+
+	_, err := NewReader(strings.NewReader("\x0bMSH")).ReadMessage()
+	expectValue(t, true, errors.Is(err, ErrTruncatedFrame))
+	_, err = NewReader(strings.NewReader("MSH")).ReadMessage()
+	expectValue(t, true, errors.Is(err, ErrInvalidFrame))
+}
+
+func TestReadMessageContext(t *testing.T) {
+	r := NewReader(strings.NewReader(frame(message) + frame(message)))
+	msg, err := r.ReadMessageContext(context.Background())
+	expectValue(t, message, msg, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.ReadMessageContext(ctx)
+	expectValue(t, context.Canceled, err)
+
+	// the next message is still there
+	msg, err = r.ReadMessageContext(context.Background())
+	expectValue(t, message, msg, err)
+	_, err = r.ReadMessageContext(context.Background())
+	expectValue(t, io.EOF, err)
+}
+
+func TestReadMessageContextInterrupt(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	r := NewReader(server)
+
+	// a read blocked on a connection gives up on cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		client.Write([]byte("\x0bMSH|"))
+		cancel()
+	}()
+	_, err := r.ReadMessageContext(ctx)
+	expectValue(t, context.Canceled, err)
+
+	// and at the deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = NewReader(server).ReadMessageContext(ctx)
+	expectValue(t, context.DeadlineExceeded, err)
+
+	// the connection is usable again afterwards
+	go NewWriter(client).WriteMessage(message)
+	msg, err := NewReader(server).ReadMessageContext(context.Background())
+	expectValue(t, message, msg, err)
+}
@@ -0,0 +1,36 @@
+package mllp
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrBlockCharacter is returned when asked to write a message that contains
+// a start or end block character, framing it would produce a corrupt frame.
+var ErrBlockCharacter = errors.New("message contains an MLLP block character")
+
+// Writer writes framed messages to a stream such as a net.Conn.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer that writes frames to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteMessage writes msg as a single frame. The frame is written with one
+// call to the underlying writer so frames from concurrent callers sharing a
+// synchronized writer are never interleaved.
+func (w *Writer) WriteMessage(msg string) error {
+	if strings.IndexByte(msg, StartBlock) != -1 || strings.IndexByte(msg, EndBlock) != -1 {
+		return ErrBlockCharacter
+	}
+	frame := make([]byte, 0, len(msg)+3)
+	frame = append(frame, StartBlock)
+	frame = append(frame, msg...)
+	frame = append(frame, EndBlock, CarriageReturn)
+	_, err := w.w.Write(frame)
+	return err
+}
@@ -0,0 +1,34 @@
+package mllp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	expectValue(t, nil, w.WriteMessage(message))
+	expectValue(t, nil, w.WriteMessage(""))
+	expectValue(t, frame(message)+frame(""), buf.String())
+
+	// what is written reads back the same
+	r := NewReader(&buf)
+	msg, err := r.ReadMessage()
+	expectValue(t, message, msg, err)
+	msg, err = r.ReadMessage()
+	expectValue(t, "", msg, err)
+}
+
+func TestWriteMessageBlockCharacters(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err := w.WriteMessage("MSH|^~\\&\x1c\r")
+	expectError(t, err, "message contains an MLLP block character")
+	expectValue(t, true, errors.Is(err, ErrBlockCharacter))
+	err = w.WriteMessage("\x0bMSH|^~\\&")
+	expectError(t, err, "message contains an MLLP block character")
+	// nothing is written for a rejected message
+	expectValue(t, 0, buf.Len())
+}
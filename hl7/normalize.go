@@ -0,0 +1,31 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Normalize returns message with every segment terminated by sep, which must
+// be \r, \n or \r\n. When sep is empty the terminator the message uses the
+// most is kept, see DetectSegmentSeparator, which is \r, the HL7 segment
+// terminator, for a message with a single segment. Blank lines are dropped
+// and the message ends with a terminator only if it did before. Segment data
+// is not touched, a carriage return escaped in a value (\X0D\) is data and
+// stays as it is.
+func Normalize(message string, sep string) (string, error) {
+	if sep != "" && sep != "\r" && sep != "\n" && sep != "\r\n" {
+		return "", fmt.Errorf("segment separator must be \\r, \\n or \\r\\n, got %q", sep)
+	}
+	detected, err := DetectSegmentSeparator(message)
+	if err != nil {
+		return "", err
+	}
+	if sep == "" {
+		sep = detected
+	}
+	normalized := strings.Join(segmentLines(message), sep)
+	if strings.HasSuffix(message, "\r") || strings.HasSuffix(message, "\n") {
+		normalized += sep
+	}
+	return normalized, nil
+}
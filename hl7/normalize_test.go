@@ -0,0 +1,38 @@
+package hl7
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	mixed := "MSH|^~\\&|HIS\r\nPID|1\nPV1|1\r\rOBX|1||a\\X0D\\b\r\n\r\n"
+	msg, err := Normalize(mixed, "\r")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1\rPV1|1\rOBX|1||a\\X0D\\b\r", msg, err)
+
+	msg, err = Normalize(mixed, "\r\n")
+	expectValue(t, "MSH|^~\\&|HIS\r\nPID|1\r\nPV1|1\r\nOBX|1||a\\X0D\\b\r\n", msg, err)
+
+	// by default the terminator used the most wins
+	msg, err = Normalize(mixed, "")
+	expectValue(t, "MSH|^~\\&|HIS\r\nPID|1\r\nPV1|1\r\nOBX|1||a\\X0D\\b\r\n", msg, err)
+
+	msg, err = Normalize("MSH|^~\\&|HIS\nPID|1\r\nPV1|1\n", "")
+	expectValue(t, "MSH|^~\\&|HIS\nPID|1\nPV1|1\n", msg, err)
+
+	msg, err = Normalize("MSH|^~\\&|HIS", "")
+	expectValue(t, "MSH|^~\\&|HIS", msg, err)
+
+	// no terminator is added at the end
+	msg, err = Normalize(message, "\n")
+	expectValue(t, strings.ReplaceAll(message, "\r", "\n"), msg, err)
+
+	msg, err = Normalize(message, "\r")
+	expectValue(t, message, msg, err)
+
+	_, err = Normalize(message, "\n\r")
+	expectError(t, err, `segment separator must be \r, \n or \r\n, got "\n\r"`)
+
+	_, err = Normalize("PID|1", "")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,20 @@
+package hl7
+
+// Null is the HL7 null value, two double quotes. A field set to it is
+// explicitly empty, telling the receiver to clear any value it has, where a
+// missing field leaves the value alone.
+const Null = `""`
+
+// AbstractHL7Null works like AbstractHL7 but tells the HL7 null value apart
+// from an empty one: isNull is true, and value empty, when the value at path
+// is exactly "". A missing or empty value is not null.
+func AbstractHL7Null(message string, path HL7Path) (value string, isNull bool, err error) {
+	value, err = AbstractHL7(message, path)
+	if err != nil {
+		return "", false, err
+	}
+	if value == Null && path != (HL7Path{}) {
+		return "", true, nil
+	}
+	return value, false, nil
+}
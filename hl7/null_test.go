@@ -0,0 +1,31 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7Null(t *testing.T) {
+	update := "MSH|^~\\&|HIS|RIH|EKG|EKG|20240101||ADT^A08|MSG00005|P|2.5\rPID|1||123^^^^MRN||DOE^\"\"|||\"\"||\"\"\"\""
+
+	for p, expected := range map[string]struct {
+		value  string
+		isNull bool
+	}{
+		"PID-8":   {"", true},
+		"PID-5.2": {"", true},
+		"PID-5.1": {"DOE", false},
+		"PID-5":   {"DOE^\"\"", false},
+		// empty and missing values are not null
+		"PID-7":  {"", false},
+		"PID-40": {"", false},
+		"PV1-2":  {"", false},
+		// only the null itself is
+		"PID-10": {`""""`, false},
+	} {
+		path, err1 := ParsePath(p)
+		value, isNull, err2 := AbstractHL7Null(update, path)
+		expectValue(t, expected.value, value, err1, err2)
+		expectValue(t, expected.isNull, isNull)
+	}
+
+	_, _, err := AbstractHL7Null("PID|1", HL7Path{Segment: "PID", SegmentIndex: 1})
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,53 @@
+package hl7
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var integerExp = regexp.MustCompile(`^[+-]?\d+$`)
+
+// AbstractHL7Int works like AbstractHL7 but parses the value as an integer,
+// like a set ID. An empty value or one that is not an integer is an error.
+func AbstractHL7Int(message string, path HL7Path) (int, error) {
+	value, err := abstractHL7Required(message, path)
+	if err != nil {
+		return 0, err
+	}
+	if !integerExp.MatchString(value) {
+		return 0, fmt.Errorf("%s: %q is not an integer", path, value)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is out of range", path, value)
+	}
+	return n, nil
+}
+
+// AbstractHL7Float works like AbstractHL7 but parses the value as a number,
+// like an OBX-5 result. The value has to be an HL7 NM, digits with an
+// optional sign and decimal point, so an empty value, 1e3 or NaN are errors.
+func AbstractHL7Float(message string, path HL7Path) (float64, error) {
+	value, err := abstractHL7Required(message, path)
+	if err != nil {
+		return 0, err
+	}
+	if !numericExp.MatchString(value) {
+		return 0, fmt.Errorf("%s: %q is not a number", path, value)
+	}
+	// an NM always fits, very large ones lose precision
+	f, _ := strconv.ParseFloat(value, 64)
+	return f, nil
+}
+
+func abstractHL7Required(message string, path HL7Path) (string, error) {
+	value, err := AbstractHL7(message, path)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s: value is empty", path)
+	}
+	return value, nil
+}
@@ -0,0 +1,41 @@
+package hl7
+
+import "testing"
+
+func TestAbstractHL7Int(t *testing.T) {
+	n, err := AbstractHL7Int(message, MustParsePath("OBX[2]-1"))
+	expectValue(t, 2, n, err)
+	n, err = AbstractHL7Int("MSH|^~\\&|HIS\rOBX|-07|+3", MustParsePath("OBX-1"))
+	expectValue(t, -7, n, err)
+	n, err = AbstractHL7Int("MSH|^~\\&|HIS\rOBX|-07|+3", MustParsePath("OBX-2"))
+	expectValue(t, 3, n, err)
+
+	_, err = AbstractHL7Int(message, MustParsePath("OBX-5"))
+	expectError(t, err, `OBX-5: "1.80" is not an integer`)
+	_, err = AbstractHL7Int(message, MustParsePath("OBX-4"))
+	expectError(t, err, "OBX-4: value is empty")
+	_, err = AbstractHL7Int(message, MustParsePath("OBX[3]-1"))
+	expectError(t, err, "OBX[3]-1: value is empty")
+	_, err = AbstractHL7Int("MSH|^~\\&|HIS\rOBX|99999999999999999999", MustParsePath("OBX-1"))
+	expectError(t, err, `OBX-1: "99999999999999999999" is out of range`)
+	_, err = AbstractHL7Int("PID|1", MustParsePath("PID-1"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestAbstractHL7Float(t *testing.T) {
+	f, err := AbstractHL7Float(message, MustParsePath("OBX[1].5"))
+	expectValue(t, 1.80, f, err)
+	f, err = AbstractHL7Float(message, MustParsePath("OBX[2]-5"))
+	expectValue(t, 79.0, f, err)
+	f, err = AbstractHL7Float("MSH|^~\\&|HIS\rOBX|-.5", MustParsePath("OBX-1"))
+	expectValue(t, -0.5, f, err)
+
+	_, err = AbstractHL7Float(message, MustParsePath("OBX-6"))
+	expectError(t, err, `OBX-6: "m" is not a number`)
+	_, err = AbstractHL7Float("MSH|^~\\&|HIS\rOBX|1e3|NaN", MustParsePath("OBX-1"))
+	expectError(t, err, `OBX-1: "1e3" is not a number`)
+	_, err = AbstractHL7Float("MSH|^~\\&|HIS\rOBX|1e3|NaN", MustParsePath("OBX-2"))
+	expectError(t, err, `OBX-2: "NaN" is not a number`)
+	_, err = AbstractHL7Float(message, MustParsePath("OBX-4"))
+	expectError(t, err, "OBX-4: value is empty")
+}
@@ -0,0 +1,176 @@
+package hl7
+
+// Options controls optional extraction behavior. The zero value matches the
+// behavior of AbstractHL7.
+type Options struct {
+	// ADDContinuation appends the content of ADD segments onto the segment
+	// they continue before extracting. Defaults to false.
+	ADDContinuation bool
+	// TrimCutset removes any of its characters from both ends of the
+	// extracted value, e.g. `"` for a sender that quotes every value.
+	// MSH-1 and MSH-2 are never trimmed. Defaults to "" (no trimming).
+	TrimCutset string
+	// ExpectMessageType makes the extraction fail unless MSH-9 matches it,
+	// e.g. "ADT^A01". Components are compared with ^ as the separator no
+	// matter what the message uses and only the components given are
+	// compared, so "ADT" matches any ADT message. Defaults to "" (no check).
+	ExpectMessageType string
+	// Truncate cuts the extracted value at the truncation character, when the
+	// message declares one in MSH-2 (^~\&#), dropping the marker and anything
+	// after it up to the next separator: every field, component and
+	// subcomponent of a segment or field is cut on its own. A message
+	// declaring a version before 2.7 in MSH-12 has no truncation character,
+	// its values are not cut. MSH-1 and MSH-2 are never cut. Defaults to
+	// false.
+	Truncate bool
+	// Unescape replaces the escape sequences in the extracted value with the
+	// characters they stand for, see Unescape. It is meant for single values,
+	// unescaping a value with components can make a separator of data.
+	// MSH-1 and MSH-2 are never unescaped. Defaults to false.
+	Unescape bool
+	// TrimNulls turns the HL7 null value "" (two double quotes) into an
+	// empty value, for callers that don't tell a null from a missing value,
+	// see AbstractHL7Null for those that do. Defaults to false.
+	TrimNulls bool
+	// TrimTrailingEmpty drops the empty fields, repetitions, components and
+	// subcomponents at the end of an extracted segment or field, so
+	// "PID|1|A^B^^||" is returned as "PID|1|A^B". MSH-1 and MSH-2 are never
+	// trimmed. Defaults to false, values are returned as they are.
+	TrimTrailingEmpty bool
+	// CaseInsensitiveSegments matches segment names in any case, for senders
+	// that write pid instead of PID, msh included. Segment names are read as
+	// upper case, so an extracted segment has its name in upper case. The
+	// HL7 standard only knows upper case names. Defaults to false.
+	CaseInsensitiveSegments bool
+	// Strict makes a path the message does not reach, a segment it does not
+	// have or a field, repetition, component or subcomponent past the last
+	// one, an *ExtractError saying where instead of an empty value. An empty
+	// value that is in the message is not an error. Defaults to false.
+	Strict bool
+}
+
+// Option sets a field of Options, see AbstractHL7Opts.
+type Option func(*Options)
+
+// WithADDContinuation stitches ADD continuation segments back onto the
+// segment that overflowed into them.
+func WithADDContinuation() Option {
+	return func(o *Options) {
+		o.ADDContinuation = true
+	}
+}
+
+// WithTrimCutset trims any of the characters in cutset from both ends of the
+// extracted value.
+func WithTrimCutset(cutset string) Option {
+	return func(o *Options) {
+		o.TrimCutset = cutset
+	}
+}
+
+// WithExpectMessageType guards the extraction so it fails on a message whose
+// MSH-9 does not match messageType.
+func WithExpectMessageType(messageType string) Option {
+	return func(o *Options) {
+		o.ExpectMessageType = messageType
+	}
+}
+
+// WithTruncation cuts extracted values at the truncation character of
+// messages that declare one.
+func WithTruncation() Option {
+	return func(o *Options) {
+		o.Truncate = true
+	}
+}
+
+// WithUnescape unescapes extracted values with the encoding characters of the
+// message.
+func WithUnescape() Option {
+	return func(o *Options) {
+		o.Unescape = true
+	}
+}
+
+// WithTrimNulls returns the HL7 null value "" as an empty value.
+func WithTrimNulls() Option {
+	return func(o *Options) {
+		o.TrimNulls = true
+	}
+}
+
+// WithTrailingEmptyFields(false) drops the empty pieces at the end of an
+// extracted segment or field, WithTrailingEmptyFields(true) keeps them, which
+// is the default.
+func WithTrailingEmptyFields(keep bool) Option {
+	return func(o *Options) {
+		o.TrimTrailingEmpty = !keep
+	}
+}
+
+// WithCaseInsensitiveSegments matches segment names whatever their case.
+func WithCaseInsensitiveSegments() Option {
+	return func(o *Options) {
+		o.CaseInsensitiveSegments = true
+	}
+}
+
+// WithStrict returns an *ExtractError for values that are not in the message.
+func WithStrict() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+func buildOptions(opts []Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// SetOptions controls optional behavior of SetHL7. The zero value matches
+// SetHL7 without options.
+type SetOptions struct {
+	// RawValue writes the value as it is, without escaping it, so its
+	// separators make repetitions, components and subcomponents: setting
+	// PID-5[1] to "DOE~SMITH" adds a repetition instead of storing
+	// DOE\R\SMITH. The caller is responsible for the value being valid HL7.
+	// Defaults to false, the value is escaped to protect the structure.
+	RawValue bool
+	// CreateMissingSegments adds the segments a path needs when the message
+	// has fewer segments of its name than its segment index: setting OBX[5]-5
+	// in a message with 2 OBX adds three OBX segments after the last OBX,
+	// OBX| and OBX| and the one holding the value, whose fields before
+	// OBX-5 are empty. A segment the message doesn't have at all is added at
+	// the end. Negative indexes and paths with a predicate never create
+	// segments. Defaults to false, the error matches ErrSegmentNotFound.
+	CreateMissingSegments bool
+}
+
+// SetOption sets a field of SetOptions, see SetHL7.
+type SetOption func(*SetOptions)
+
+// WithRawValue writes the value without escaping it, see SetOptions.RawValue.
+func WithRawValue() SetOption {
+	return func(o *SetOptions) {
+		o.RawValue = true
+	}
+}
+
+// WithCreateMissingSegments adds the segments a path needs instead of
+// failing, see SetOptions.CreateMissingSegments.
+func WithCreateMissingSegments() SetOption {
+	return func(o *SetOptions) {
+		o.CreateMissingSegments = true
+	}
+}
+
+func buildSetOptions(opts []SetOption) SetOptions {
+	o := SetOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,153 @@
+package hl7
+
+import (
+	"strings"
+	"testing"
+)
+
+var optionsMessage = "MSH|^~\\&|HIS|RIH|||20240101||ADT^A08|MSG00004|P|2.5||\rPID|1||123^^^^MRN^^||\"\"|DOE^JANE^^&&^|||\"\"|\"\"^F||2222 HOMES\\F\\TREET\\E\\1^^GREENSBORO|||"
+
+func TestAbstractHL7OptsUnescape(t *testing.T) {
+	path, err1 := ParsePath("PID-12.1")
+	resp, err2 := AbstractHL7Opts(optionsMessage, path)
+	expectValue(t, "2222 HOMES\\F\\TREET\\E\\1", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithUnescape())
+	expectValue(t, "2222 HOMES|TREET\\1", resp, err1, err2)
+
+	// custom escape characters are used
+	custom := "MSH#!@$%#HIS\rPID#1#A$F$B$E$"
+	resp, err2 = AbstractHL7Opts(custom, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 2, RepetitionIndex: 1}, WithUnescape())
+	expectValue(t, "A#B$", resp, err2)
+}
+
+func TestAbstractHL7OptsTrimNulls(t *testing.T) {
+	path, err1 := ParsePath("PID-5")
+	resp, err2 := AbstractHL7Opts(optionsMessage, path)
+	expectValue(t, `""`, resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrimNulls())
+	expectValue(t, "", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-10.1")
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrimNulls())
+	expectValue(t, "", resp, err1, err2)
+
+	// only a value that is nothing but the null is null
+	path, err1 = ParsePath("PID-10")
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrimNulls())
+	expectValue(t, `""^F`, resp, err1, err2)
+}
+
+func TestAbstractHL7OptsTrailingEmptyFields(t *testing.T) {
+	path, err1 := ParsePath("PID")
+	resp, err2 := AbstractHL7Opts(optionsMessage, path)
+	expectValue(t, "PID|1||123^^^^MRN^^||\"\"|DOE^JANE^^&&^|||\"\"|\"\"^F||2222 HOMES\\F\\TREET\\E\\1^^GREENSBORO|||", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrailingEmptyFields(false))
+	expectValue(t, "PID|1||123^^^^MRN^^||\"\"|DOE^JANE^^&&^|||\"\"|\"\"^F||2222 HOMES\\F\\TREET\\E\\1^^GREENSBORO", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrailingEmptyFields(true))
+	expectValue(t, "PID|1||123^^^^MRN^^||\"\"|DOE^JANE^^&&^|||\"\"|\"\"^F||2222 HOMES\\F\\TREET\\E\\1^^GREENSBORO|||", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-3")
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrailingEmptyFields(false))
+	expectValue(t, "123^^^^MRN", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-6")
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrailingEmptyFields(false))
+	expectValue(t, "DOE^JANE", resp, err1, err2)
+
+	// the encoding characters of MSH are kept
+	path, err1 = ParsePath("MSH")
+	resp, err2 = AbstractHL7Opts(optionsMessage, path, WithTrailingEmptyFields(false))
+	expectValue(t, "MSH|^~\\&|HIS|RIH|||20240101||ADT^A08|MSG00004|P|2.5", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts("MSH|^~\\&||", path, WithTrailingEmptyFields(false))
+	expectValue(t, "MSH|^~\\&", resp, err1, err2)
+}
+
+// lowercaseMessage is the kind of message a non-conformant sender writes,
+// with lower case segment names.
+var lowercaseMessage = "msh|^~\\&|HIS|RIH|||20240101||ADT^A01|MSG00005|P|2.5\rpid|1||123^^^^MRN||DOE^JANE\robx|1|ST|^Note||first\rObx|2|ST|^Note||second\rzpi|custom"
+
+func TestAbstractHL7OptsCaseInsensitiveSegments(t *testing.T) {
+	// names are case sensitive by default
+	path, err1 := ParsePath("PID-5.2")
+	_, err2 := AbstractHL7Opts(lowercaseMessage, path)
+	expectError(t, err2, "invalid HL7 message: must begin with MSH")
+	resp, err2 := AbstractHL7Opts(strings.Replace(lowercaseMessage, "msh", "MSH", 1), path)
+	expectValue(t, "", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(lowercaseMessage, path, WithCaseInsensitiveSegments())
+	expectValue(t, "JANE", resp, err1, err2)
+
+	// MSH keeps its field numbering
+	for p, expected := range map[string]string{
+		"MSH-1":     "|",
+		"MSH-2":     "^~\\&",
+		"MSH-9.2":   "A01",
+		"MSH-10":    "MSG00005",
+		"OBX[2]-5":  "second",
+		"OBX[-2]-5": "first",
+		"ZPI-1":     "custom",
+	} {
+		path, err1 = ParsePath(p)
+		resp, err2 = AbstractHL7Opts(lowercaseMessage, path, WithCaseInsensitiveSegments())
+		expectValue(t, expected, resp, err1, err2)
+	}
+
+	// whole segments come back with their name in upper case
+	path, err1 = ParsePath("OBX[2]")
+	resp, err2 = AbstractHL7Opts(lowercaseMessage, path, WithCaseInsensitiveSegments())
+	expectValue(t, "OBX|2|ST|^Note||second", resp, err1, err2)
+
+	// other options still apply
+	path, err1 = ParsePath("MSH-9")
+	resp, err2 = AbstractHL7Opts(lowercaseMessage, path, WithCaseInsensitiveSegments(), WithExpectMessageType("ADT^A01"))
+	expectValue(t, "ADT^A01", resp, err1, err2)
+
+	// the empty path is still the message as it was given
+	resp, err2 = AbstractHL7Opts(lowercaseMessage, HL7Path{}, WithCaseInsensitiveSegments())
+	expectValue(t, lowercaseMessage, resp, err2)
+}
+
+func TestAbstractHL7OptsEncodingCharacters(t *testing.T) {
+	// MSH-2 holds the repetition and escape characters but is returned whole
+	// with every option, alone and together
+	opts := []Option{
+		WithADDContinuation(), WithTrimCutset("^~\\&#"), WithExpectMessageType("ADT"), WithTruncation(),
+		WithUnescape(), WithTrimNulls(), WithTrailingEmptyFields(false), WithCaseInsensitiveSegments(),
+	}
+	msh1, msh2 := MustParsePath("MSH-1"), MustParsePath("MSH-2")
+	for _, test := range []struct{ message, encoding string }{
+		{message, "^~\\&"},
+		{truncatedMessage, "^~\\&#"},
+		{"MSH#!@$%#HIS#RIH#####ADT", "!@$%"},
+	} {
+		for _, opt := range opts {
+			resp, err := AbstractHL7Opts(test.message, msh2, opt)
+			expectValue(t, test.encoding, resp, err)
+		}
+		resp, err := AbstractHL7Opts(test.message, msh2, opts...)
+		expectValue(t, test.encoding, resp, err)
+		resp, err = AbstractHL7Opts(test.message, msh1, opts...)
+		expectValue(t, test.message[3:4], resp, err)
+
+		// and by every other way of reading it
+		resp, err = AbstractHL7(test.message, msh2)
+		expectValue(t, test.encoding, resp, err)
+		resp, err = AbstractHL7View(test.message, msh2)
+		expectValue(t, test.encoding, resp, err)
+		values, err := AbstractHL7All(test.message, MustParsePath("MSH-2[*]"))
+		expectDeepValue(t, []string{test.encoding}, values, err)
+		m, err1 := Parse(test.message)
+		resp, err2 := m.Get(msh2)
+		expectValue(t, test.encoding, resp, err1, err2)
+		e, err1 := NewExtractor(test.message)
+		resp, err2 = e.Get(msh2)
+		expectValue(t, test.encoding, resp, err1, err2)
+		count, err := RepetitionCount(test.message, msh2)
+		expectValue(t, 1, count, err)
+	}
+}
@@ -1,11 +1,34 @@
 package hl7
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"slices"
+	"strings"
+	"unicode"
 )
 
+// WildcardRepetition as a RepetitionIndex selects every repetition of the
+// field. It is written as [*] in a path, e.g. PID-3[*], and can only be
+// extracted with AbstractHL7All.
+const WildcardRepetition = math.MinInt32
+
+// WildcardComponent as a Component selects every component of the
+// repetition, in order. It is written as * in a path, e.g. PID-5.*, and can
+// only be extracted with AbstractHL7All.
+const WildcardComponent = math.MinInt32
+
+// WildcardSubcomponent as a Subcomponent selects every subcomponent of the
+// component, in order. It is written as * in a path, e.g. PID-3.4.*, and can
+// only be extracted with AbstractHL7All.
+const WildcardSubcomponent = math.MinInt32
+
+// HL7Path is the location of a value in a message, see ParsePath. It is a
+// plain value that nothing in this package modifies, so a path, or a slice of
+// them, can be parsed once and shared by any number of goroutines.
 type HL7Path struct {
 	Segment         string `json:"segment"`
 	SegmentIndex    int    `json:"segment_index"`
@@ -13,50 +36,261 @@ type HL7Path struct {
 	RepetitionIndex int    `json:"repetition_index,omitempty"`
 	Component       int    `json:"component,omitempty"`
 	Subcomponent    int    `json:"subcomponent,omitempty"`
+	// Where, when set, only counts the segments named Segment that hold its
+	// value, SegmentIndex is then the index among those. It is left out of
+	// the JSON of a path without one.
+	Where SegmentPredicate `json:"where"`
+}
+
+// MarshalJSON encodes the path with the tags of HL7Path, leaving Where out
+// when it is the zero SegmentPredicate.
+func (p HL7Path) MarshalJSON() ([]byte, error) {
+	// fields has the fields of HL7Path without its methods, the outer Where
+	// hides the one in it
+	type fields HL7Path
+	out := struct {
+		fields
+		Where *SegmentPredicate `json:"where,omitempty"`
+	}{fields: fields(p)}
+	if p.Where != (SegmentPredicate{}) {
+		out.Where = &p.Where
+	}
+	return json.Marshal(out)
+}
+
+// SegmentPredicate selects segments by a value they hold rather than by their
+// position, written in braces after the segment name of a path, e.g.
+// OBX{3.2=Body Weight}-5 is OBX-5 of the first OBX whose OBX-3.2 is Body
+// Weight. The value is compared with the first repetition of the field as it
+// is in the message, escape sequences included, and can't contain braces.
+// The zero SegmentPredicate selects every segment.
+type SegmentPredicate struct {
+	Field        int    `json:"field,omitempty"`
+	Component    int    `json:"component,omitempty"`
+	Subcomponent int    `json:"subcomponent,omitempty"`
+	Value        string `json:"value,omitempty"`
+}
+
+// String renders the predicate the way ParsePath reads it, like
+// {3.2=Body Weight}, or "" for the zero SegmentPredicate.
+func (w SegmentPredicate) String() string {
+	if w == (SegmentPredicate{}) {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "{%d", w.Field)
+	if w.Component != 0 {
+		fmt.Fprintf(&b, ".%d", w.Component)
+	}
+	if w.Subcomponent != 0 {
+		fmt.Fprintf(&b, ".%d", w.Subcomponent)
+	}
+	b.WriteString("=" + w.Value + "}")
+	return b.String()
 }
 
+// path is the location the predicate compares in a segment named segment.
+func (w SegmentPredicate) path(segment string) HL7Path {
+	return HL7Path{Segment: segment, SegmentIndex: 1, Field: w.Field, RepetitionIndex: 1, Component: w.Component, Subcomponent: w.Subcomponent}
+}
+
+// Validate checks that the path is consistent, an error matches
+// ErrInvalidPath.
 func (p HL7Path) Validate() error {
+	if errs := p.violations(); len(errs) > 0 {
+		return &kindError{ErrInvalidPath, errs[0]}
+	}
+	return nil
+}
+
+// ValidateAll is like Validate but returns every rule the path breaks instead
+// of only the first, in the order Validate checks them, or nil for a valid
+// path. Every error matches ErrInvalidPath.
+func (p HL7Path) ValidateAll() []error {
+	var errs []error
+	for _, err := range p.violations() {
+		errs = append(errs, &kindError{ErrInvalidPath, err})
+	}
+	return errs
+}
+
+// ValidatePathForMessage checks path against message, beyond Validate: the
+// message must have a valid MSH header for the path to be read at all, see
+// ValidateMSH, and the path must not ask for structure a field does not have.
+// MSH-1 and MSH-2 hold the encoding characters, they have no repetitions,
+// components or subcomponents, so MSH-2.3 or MSH-2[2] are mistakes rather
+// than empty values. A path to a value the message doesn't have is not an
+// error. Errors about the path match ErrInvalidPath, about the message
+// ErrInvalidMessage.
+func ValidatePathForMessage(message string, path HL7Path) error {
+	if err := path.Validate(); err != nil {
+		return err
+	}
+	if _, err := ValidateMSH(message); err != nil {
+		return err
+	}
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		name := fmt.Sprintf("MSH-%d", path.Field)
+		switch path.RepetitionIndex {
+		case 1, -1, WildcardRepetition:
+		default:
+			return &kindError{ErrInvalidPath, fmt.Errorf("%s holds the encoding characters, it has no repetition %d", name, path.RepetitionIndex)}
+		}
+		if path.Component != 0 {
+			return &kindError{ErrInvalidPath, fmt.Errorf("%s holds the encoding characters, it has no components", name)}
+		}
+	}
+	return nil
+}
+
+// violations lists the rules the path breaks, see Validate.
+func (p HL7Path) violations() []error {
+	var errs []error
 	// TODO: do advanced validation based on a specific HL7 version and schema.
 	// if Segment is "" then the rest must be empty or 0
 	if p.Segment == "" {
-		if p.SegmentIndex != 0 || p.Field != 0 || p.RepetitionIndex != 0 || p.Component != 0 || p.Subcomponent != 0 {
-			return errors.New("if Segment is empty, the rest of the path must be empty or 0")
+		if p.Where != (SegmentPredicate{}) || p.SegmentIndex != 0 || p.Field != 0 || p.RepetitionIndex != 0 || p.Component != 0 || p.Subcomponent != 0 {
+			return []error{errors.New("if Segment is empty, the rest of the path must be empty or 0")}
 		}
 		return nil
 	}
 	// if Segment is MSH then SegmentIndex must be 1
 	if p.Segment == "MSH" && p.SegmentIndex != 1 {
-		return errors.New("if Segment is MSH, SegmentIndex must be 1")
+		errs = append(errs, errors.New("if Segment is MSH, SegmentIndex must be 1"))
 	}
 	// if Segment is MSH and Field is 1, then the rest must be empty or 0
 	if p.Segment == "MSH" && p.Field == 1 {
 		if p.Component != 0 || p.Subcomponent != 0 {
-			return errors.New("if Segment is MSH and Field is 1, the rest of the path must be empty or 0")
+			errs = append(errs, errors.New("if Segment is MSH and Field is 1, the rest of the path must be empty or 0"))
+		}
+	}
+	// the predicate is a position in the segment like any other
+	if p.Where != (SegmentPredicate{}) {
+		if p.Where.Field < 1 || p.Where.Component < 0 || p.Where.Subcomponent < 0 {
+			errs = append(errs, errors.New("if Where is set, Where.Field must be at least 1 and its component and subcomponent can't be negative"))
+		}
+		if p.Where.Subcomponent != 0 && p.Where.Component == 0 {
+			errs = append(errs, errors.New("if Where.Subcomponent is set, Where.Component must be set"))
+		}
+		if strings.ContainsAny(p.Where.Value, "{}") {
+			errs = append(errs, errors.New("Where.Value can't contain { or }"))
 		}
 	}
 	// if Field is set, then Segment must be set
 	if p.Field != 0 && p.Segment == "" {
-		return errors.New("if Field is set, Segment must be set")
+		errs = append(errs, errors.New("if Field is set, Segment must be set"))
 	}
 	// if RepetitionIndex is set, then Field must be set
 	if p.RepetitionIndex != 0 && p.Field == 0 {
-		return errors.New("if RepetitionIndex is set, Field must be set")
+		errs = append(errs, errors.New("if RepetitionIndex is set, Field must be set"))
 	}
 	// if Field is set, then RepeitionIndex must be at least 1
 	if p.Field != 0 && p.RepetitionIndex == 0 {
-		return errors.New("if Field is set, RepetitionIndex must be at least 1")
+		errs = append(errs, errors.New("if Field is set, RepetitionIndex must be at least 1"))
 	}
 	// if Component is set, then Field must be set
 	if p.Component != 0 && p.Field == 0 {
-		return errors.New("if Component is set, Field must be set")
+		errs = append(errs, errors.New("if Component is set, Field must be set"))
 	}
 	// if Subcomponent is set, then Component must be set
 	if p.Subcomponent != 0 && p.Component == 0 {
-		return errors.New("if Subcomponent is set, Component must be set")
+		errs = append(errs, errors.New("if Subcomponent is set, Component must be set"))
 	}
-	return nil
+	// every component has its own subcomponents, there is no one to pick
+	if p.Subcomponent != 0 && p.Component == WildcardComponent {
+		errs = append(errs, errors.New("if Component is a wildcard, Subcomponent must be empty or 0"))
+	}
+	// a path selects a list of values, not a list of lists
+	if p.RepetitionIndex == WildcardRepetition && (p.Component == WildcardComponent || p.Subcomponent == WildcardSubcomponent) {
+		errs = append(errs, errors.New("a path can only have one wildcard"))
+	}
+	return errs
 }
 
+// PathStyle is the separator HL7Path.StringWith writes between the segment
+// and the field, ParsePath reads either.
+type PathStyle int
+
+const (
+	// HyphenStyle separates the segment and the field with a hyphen and the
+	// levels below the field with dots, like PID-5.1. It is the style of
+	// HL7Path.String.
+	HyphenStyle PathStyle = iota
+	// DotStyle separates every level with a dot, like PID.5.1.
+	DotStyle
+)
+
+// String renders the path in the canonical form ParsePath reads, like
+// PID[2]-3[4].5.6. Indexes of 1 are left out, as ParsePath defaults them, so
+// ParsePath(p.String()) returns p for any valid path.
+func (p HL7Path) String() string {
+	return p.StringWith(HyphenStyle)
+}
+
+// StringWith is String in the given style, PID.3[4].5.6 with DotStyle.
+// ParsePath(p.StringWith(style)) returns p for any valid path and style.
+func (p HL7Path) StringWith(style PathStyle) string {
+	if p.Segment == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(p.Segment)
+	b.WriteString(p.Where.String())
+	if p.SegmentIndex != 1 {
+		fmt.Fprintf(&b, "[%d]", p.SegmentIndex)
+	}
+	if p.Field == 0 {
+		return b.String()
+	}
+	fieldSeparator := '-'
+	if style == DotStyle {
+		fieldSeparator = '.'
+	}
+	fmt.Fprintf(&b, "%c%d", fieldSeparator, p.Field)
+	switch p.RepetitionIndex {
+	case 1:
+	case WildcardRepetition:
+		b.WriteString("[*]")
+	default:
+		fmt.Fprintf(&b, "[%d]", p.RepetitionIndex)
+	}
+	switch p.Component {
+	case 0:
+	case WildcardComponent:
+		b.WriteString(".*")
+	default:
+		fmt.Fprintf(&b, ".%d", p.Component)
+	}
+	switch p.Subcomponent {
+	case 0:
+	case WildcardSubcomponent:
+		b.WriteString(".*")
+	default:
+		fmt.Fprintf(&b, ".%d", p.Subcomponent)
+	}
+	return b.String()
+}
+
+// resolveIndex turns an index counted from the end, where -1 is the last of
+// count, into the 1-based index it refers to. Positive indexes are returned as
+// they are. The result is below 1 when a negative index is out of range.
+func resolveIndex(index int, count int) int {
+	if index < 0 {
+		return count + index + 1
+	}
+	return index
+}
+
+// hasWildcard reports whether the path selects more than one value.
+func (p HL7Path) hasWildcard() bool {
+	return p.RepetitionIndex == WildcardRepetition || p.Component == WildcardComponent || p.Subcomponent == WildcardSubcomponent
+}
+
+// deepPathExp matches a path with any number of levels after the segment so
+// a path that is only invalid because it is too deep can be told apart.
+var deepPathExp = regexp.MustCompile(`^[A-Z][A-Z0-9]{2}(?:\[-?\d+\])?((?:[-\.](?:\d+|\*)(?:\[(?:-?\d+|\*)\])?)+)$`)
+var levelExp = regexp.MustCompile(`[-\.](?:\d+|\*)`)
+
 func ParsePath(path string) (HL7Path, error) {
 	/*
 		 * Need to support the following path formats:
@@ -73,7 +307,16 @@ func ParsePath(path string) (HL7Path, error) {
 
 		  - Support either - or . as separators
 		  - Indexes are optional and default to 1 if not provided
+		  - The repetition index can be * to select every repetition, and
+		    the component or subcomponent can be * to select every one of
+		    them
 		  - Indexes are 1-based, not 0-based
+		  - Segment and repetition indexes can be negative to count from the
+		    end, -1 is the last one
+		  - [first] and [last] can be written for [1] and [-1]
+		  - The segment name can be followed by a predicate in braces,
+		    {FIELD[.COMPONENT[.SUBCOMPONENT]]=VALUE}, to only count the
+		    segments holding VALUE there, see SegmentPredicate
 
 
 		 * Example Paths:
@@ -81,30 +324,36 @@ func ParsePath(path string) (HL7Path, error) {
 		  - PV1-2 would be PV1,1,2
 		  - MSH-10 would be MSH,1,10
 		  - OBX[2].5.2 would be OBX,2,5,1,2
+		  - PID-3[*].1 would be PID,1,3,WildcardRepetition,1
+		  - PID-5.* would be PID,1,5,1,WildcardComponent
+		  - PID-3.4.* would be PID,1,3,1,4,WildcardSubcomponent
+		  - OBX[-1]-5[-2] would be OBX,-1,5,-2
+		  - OBX[last]-5[first] would be OBX,-1,5,1
+		  - OBX{3.2=Body Weight}-5 would be OBX,{3.2=Body Weight},1,5
 	*/
 
-	// seg & segIndex = ([A-Z0-9]{3})(?:\[(\d+)\])?
-	// field & repetitionIndex = (?:[-\.](\d+)(?:\[(\d+)\])?)?
-	// component = (?:[-\.](\d+))?
-	// subcomponent = (?:[-\.](\d+))?
+	// seg & segIndex = ([A-Z0-9]{3})(?:\[(-?\d+)\])?
+	// field & repetitionIndex = (?:[-\.](\d+)(?:\[(-?\d+|\*)\])?)?
+	// component = (?:[-\.](\d+|\*))?
+	// subcomponent = (?:[-\.](\d+|\*))?
 	/*
 		full regexp:
-		^([A-Z][A-Z0-9]{2})(?:\[(\d+)\])?(?:[-\.](\d+)(?:\[(\d+)\])?(?:[-\.](\d+)(?:[-\.](\d+))?)?)?$
+		^([A-Z][A-Z0-9]{2})(?:\[(-?\d+)\])?(?:[-\.](\d+)(?:\[(-?\d+|\*)\])?(?:[-\.](\d+|\*)(?:[-\.](\d+|\*))?)?)?$
 
 		regexp explanation:
 		^ // start of string
 		([A-Z][A-Z0-9]{2}) // segment name: 3 characters, first must be a letter, the rest can be letters or digits
-		(?:\[(\d+)\])? // optional segment index in square brackets
+		(?:\[(-?\d+)\])? // optional segment index in square brackets, negative counts from the end
 		(?:
 			[-\.] // separator for field either - or .
 			(\d+) // field number
-			(?:\[(\d+)\])? // optional repetition index in square brackets
+			(?:\[(-?\d+|\*)\])? // optional repetition index (or * for all) in square brackets
 			(?:
 				[-\.] // separator for component either - or .
-				(\d+) // component number
+				(\d+|\*) // component number, or * for all
 				(?:
 					[-\.] // separator for subcomponent either - or .
-					(\d+) // subcomponent number
+					(\d+|\*) // subcomponent number, or * for all
 				)? // optional separator and subcomponent
 			)? // optional component and subcomponent with separators
 		)? // optional field, repetition index, component, and subcomponent with separators
@@ -117,6 +366,26 @@ func ParsePath(path string) (HL7Path, error) {
 		return res, nil
 	}
 
+	// the value of a segment predicate can hold anything but braces, so it is
+	// taken out before the rest of the path is looked at
+	path, where, err := cutSegmentPredicate(path)
+	if err != nil {
+		return res, err
+	}
+	res.Where = where
+
+	// the readable aliases of the first and last index
+	path = strings.NewReplacer("[first]", "[1]", "[last]", "[-1]").Replace(path)
+
+	// a level can't be skipped, so PID..1 or PID--1 does not mean "the first
+	// field" or anything else. Call this out specifically instead of the
+	// generic invalid format error.
+	for i := 0; i+1 < len(path); i++ {
+		if isPathSeparator(path[i]) && isPathSeparator(path[i+1]) {
+			return res, fmt.Errorf("%w format: %q has no value between separators", ErrInvalidPath, path[i:i+2])
+		}
+	}
+
 	captureGroups := []string{
 		"segment",
 		"segmentIndex",
@@ -125,11 +394,16 @@ func ParsePath(path string) (HL7Path, error) {
 		"component",
 		"subcomponent",
 	}
-	pathExp := regexp.MustCompile(`^([A-Z][A-Z0-9]{2})(?:\[(\d+)\])?(?:[-\.](\d+)(?:\[(\d+)\])?(?:[-\.](\d+)(?:[-\.](\d+))?)?)?$`)
+	pathExp := regexp.MustCompile(`^([A-Z][A-Z0-9]{2})(?:\[(-?\d+)\])?(?:[-\.](\d+)(?:\[(-?\d+|\*)\])?(?:[-\.](\d+|\*)(?:[-\.](\d+|\*))?)?)?$`)
 
 	match := pathExp.FindStringSubmatch(path)
 	if match == nil {
-		return res, errors.New("invalid path format")
+		// a common mistake is one separator too many, like PID-3.1.2.3, so
+		// tell the user why instead of the generic error
+		if levels := deepPathExp.FindStringSubmatch(path); levels != nil && len(levelExp.FindAllString(levels[1], -1)) > 3 {
+			return res, fmt.Errorf("%w format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT", ErrInvalidPath)
+		}
+		return res, fmt.Errorf("%w format", ErrInvalidPath)
 	}
 
 	// DEBUGGING: pathExp.SubexpNames only returns the names of captured groups.
@@ -142,7 +416,7 @@ func ParsePath(path string) (HL7Path, error) {
 		case "segment":
 			segment, err := parseSegmentNameOrError(data)
 			if err != nil {
-				return res, err
+				return res, &kindError{ErrInvalidPath, err}
 			}
 			res.Segment = segment
 		case "segmentIndex":
@@ -150,14 +424,26 @@ func ParsePath(path string) (HL7Path, error) {
 		case "field":
 			res.Field = parseIntOrDefault(data, 0)
 		case "repetitionIndex":
+			if data == "*" {
+				res.RepetitionIndex = WildcardRepetition
+				continue
+			}
 			def := 0
 			if res.Field > 0 {
 				def = 1
 			}
 			res.RepetitionIndex = parseIntOrDefault(data, def)
 		case "component":
+			if data == "*" {
+				res.Component = WildcardComponent
+				continue
+			}
 			res.Component = parseIntOrDefault(data, 0)
 		case "subcomponent":
+			if data == "*" {
+				res.Subcomponent = WildcardSubcomponent
+				continue
+			}
 			res.Subcomponent = parseIntOrDefault(data, 0)
 		}
 	}
@@ -165,6 +451,96 @@ func ParsePath(path string) (HL7Path, error) {
 	return res, nil
 }
 
+// predicateExp matches the inside of the braces of a segment predicate, see
+// SegmentPredicate.
+var predicateExp = regexp.MustCompile(`^(\d+)(?:[-\.](\d+)(?:[-\.](\d+))?)?=([^{}]*)$`)
+
+// cutSegmentPredicate parses the segment predicate that follows the segment
+// name of path, if there is one, and returns path without it.
+func cutSegmentPredicate(path string) (string, SegmentPredicate, error) {
+	if len(path) < 4 || path[3] != '{' {
+		return path, SegmentPredicate{}, nil
+	}
+	end := strings.IndexByte(path, '}')
+	if end == -1 {
+		return path, SegmentPredicate{}, fmt.Errorf("%w format: the segment predicate has no closing }", ErrInvalidPath)
+	}
+	match := predicateExp.FindStringSubmatch(path[4:end])
+	if match == nil {
+		return path, SegmentPredicate{}, fmt.Errorf("%w format: segment predicate %q must be like {FIELD.COMPONENT.SUBCOMPONENT=VALUE}", ErrInvalidPath, path[3:end+1])
+	}
+	where := SegmentPredicate{
+		Field:        parseIntOrDefault(match[1], 0),
+		Component:    parseIntOrDefault(match[2], 0),
+		Subcomponent: parseIntOrDefault(match[3], 0),
+		Value:        match[4],
+	}
+	return path[:3] + path[end+1:], where, nil
+}
+
+// ParsePathSep is like ParsePath but also accepts seps as separators between
+// the levels of the path, so with '/' PID/5/1 is PID-5.1. - and . are always
+// accepted. A separator can't be a letter, a digit, *, a square bracket, a
+// brace or = as those are part of the path themselves.
+func ParsePathSep(path string, seps ...rune) (HL7Path, error) {
+	for _, sep := range seps {
+		if unicode.IsLetter(sep) || unicode.IsDigit(sep) || strings.ContainsRune("*[]{}=", sep) {
+			return HL7Path{}, fmt.Errorf("%w separator %q: separators can't be letters, digits, *, square brackets, braces or =", ErrInvalidPath, sep)
+		}
+	}
+	inPredicate := false
+	path = strings.Map(func(r rune) rune {
+		// the value of a segment predicate is not part of the path
+		if r == '{' || r == '}' {
+			inPredicate = r == '{'
+		}
+		// - is left alone, it is also the sign of a negative index
+		if !inPredicate && r != '-' && slices.Contains(seps, r) {
+			return '.'
+		}
+		return r
+	}, path)
+	return ParsePath(path)
+}
+
+// pathPrefixExp matches the longest path at the start of a string, the
+// levels of ParsePath's pathExp plus a segment predicate and the [first] and
+// [last] aliases.
+var pathPrefixExp = regexp.MustCompile(`^[A-Z][A-Z0-9]{2}(?:\{[^{}]*\})?(?:\[(?:-?\d+|first|last)\])?(?:[-\.]\d+(?:\[(?:-?\d+|\*|first|last)\])?(?:[-\.](?:\d+|\*)(?:[-\.](?:\d+|\*))?)?)?`)
+
+// ParsePathPrefix parses the longest path at the start of s and returns it
+// with the rest of s, so a path can be part of a larger expression, like
+// PID-8:unknown giving PID-8 and ":unknown". The rest is not checked, so
+// PID-3.1.2.3 gives PID-3.1.2 and ".3", callers decide what may follow a
+// path. An empty s is the empty path, like for ParsePath, but s has to start
+// with a path otherwise.
+func ParsePathPrefix(s string) (HL7Path, string, error) {
+	if s == "" {
+		return HL7Path{}, "", nil
+	}
+	prefix := pathPrefixExp.FindString(s)
+	if prefix == "" {
+		return HL7Path{}, s, fmt.Errorf("%w format: %q does not start with a path", ErrInvalidPath, s)
+	}
+	path, err := ParsePath(prefix)
+	if err != nil {
+		return HL7Path{}, s, err
+	}
+	return path, s[len(prefix):], nil
+}
+
+// MustParsePath is like ParsePath but panics if the path can't be parsed. It
+// is meant for paths that are constants in the source, like
+// var patientName = MustParsePath("PID-5"), never for paths that come from
+// input.
+func MustParsePath(path string) HL7Path {
+	p, err := ParsePath(path)
+	if err != nil {
+		panic(fmt.Sprintf("hl7: ParsePath(%q): %v", path, err))
+	}
+	return p
+}
+
 func parseSegmentNameOrError(s string) (string, error) {
 	if len(s) != 3 {
 		return "", errors.New("segment name must be 3 characters")
@@ -192,3 +568,7 @@ func parseIntOrDefault(s string, defaultVal int) int {
 	}
 	return res
 }
+
+func isPathSeparator(c byte) bool {
+	return c == '-' || c == '.'
+}
@@ -1,6 +1,12 @@
 package hl7
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
 
 type testCase struct {
 	name     string
@@ -60,6 +66,20 @@ func expectValue(t *testing.T, expected any, received any, errors ...error) {
 	}
 }
 
+// expectDeepValue is expectValue for values that can't be compared with !=
+// such as slices and maps.
+func expectDeepValue(t *testing.T, expected any, received any, errors ...error) {
+	t.Helper()
+	for _, err := range errors {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("\nExpected: %#v\nReceived: %#v", expected, received)
+	}
+}
+
 func expectError(t *testing.T, err error, expectedError ...string) {
 	t.Helper()
 	if len(expectedError) > 1 {
@@ -90,3 +110,370 @@ func TestParseSegmentNameOrError(t *testing.T) {
 	_, err = parseSegmentNameOrError(v4)
 	expectError(t, err, "segment name must be uppercase alphanumeric")
 }
+
+func TestParsePathEmptyLevel(t *testing.T) {
+	_, err := ParsePath("PID[1]..1")
+	expectError(t, err, `invalid path format: ".." has no value between separators`)
+
+	_, err = ParsePath("PID--1")
+	expectError(t, err, `invalid path format: "--" has no value between separators`)
+
+	_, err = ParsePath("PID-3.-1")
+	expectError(t, err, `invalid path format: ".-" has no value between separators`)
+}
+
+func TestParsePathTooDeep(t *testing.T) {
+	_, err := ParsePath("PID-3.1.2.3")
+	expectError(t, err, "invalid path format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT")
+
+	_, err = ParsePath("PID[1]-3[2].1.2.3.4")
+	expectError(t, err, "invalid path format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT")
+
+	// other malformed paths still get the generic error
+	_, err = ParsePath("PID-3.1[2]")
+	expectError(t, err, "invalid path format")
+}
+
+func TestParsePathWildcardRepetition(t *testing.T) {
+	path, err := ParsePath("PID[2]-3[*].4.2")
+	expectValue(t, HL7Path{
+		Segment:         "PID",
+		SegmentIndex:    2,
+		Field:           3,
+		RepetitionIndex: WildcardRepetition,
+		Component:       4,
+		Subcomponent:    2,
+	}, path, err)
+
+	_, err = ParsePath("PID[*]-3")
+	expectError(t, err, "invalid path format")
+}
+
+func TestParsePathNegativeIndex(t *testing.T) {
+	path, err := ParsePath("OBX[-1]-5[-2].1")
+	expectValue(t, HL7Path{
+		Segment:         "OBX",
+		SegmentIndex:    -1,
+		Field:           5,
+		RepetitionIndex: -2,
+		Component:       1,
+	}, path, err)
+
+	// only indexes can be negative
+	_, err = ParsePath("PID-3.-1")
+	expectError(t, err, `invalid path format: ".-" has no value between separators`)
+
+	_, err = ParsePath("PID-3[1-]")
+	expectError(t, err, "invalid path format")
+}
+
+func TestParsePathFirstLast(t *testing.T) {
+	path, err := ParsePath("OBX[last]")
+	expectValue(t, HL7Path{Segment: "OBX", SegmentIndex: -1}, path, err)
+	path, err = ParsePath("OBX[first]-5")
+	expectValue(t, MustParsePath("OBX-5"), path, err)
+	path, err = ParsePath("PID-3[last].1")
+	expectValue(t, MustParsePath("PID-3[-1].1"), path, err)
+
+	// last is resolved against the message at extraction
+	resp, err := AbstractHL7(message, MustParsePath("OBX[last]-3.2"))
+	expectValue(t, "Body Weight", resp, err)
+	resp, err = AbstractHL7(message, MustParsePath("OBX[first]-3.2"))
+	expectValue(t, "Body Height", resp, err)
+	resp, err = AbstractHL7(message, MustParsePath("PID[last]-5[last].1"))
+	expectValue(t, "QUE", resp, err)
+	_, err = AbstractHL7(message, MustParsePath("MSH[last]-10"))
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+
+	_, err = ParsePath("OBX[LAST]")
+	expectError(t, err, "invalid path format")
+	_, err = ParsePath("OBX[final]")
+	expectError(t, err, "invalid path format")
+}
+
+func TestParsePathPredicate(t *testing.T) {
+	path, err := ParsePath("OBX{3.2=Body Weight}-5")
+	expected := HL7Path{Segment: "OBX", Where: SegmentPredicate{Field: 3, Component: 2, Value: "Body Weight"}, SegmentIndex: 1, Field: 5, RepetitionIndex: 1}
+	expectValue(t, expected, path, err)
+	expectValue(t, "OBX{3.2=Body Weight}-5", path.String())
+
+	// the value is taken as it is, separators, brackets and all
+	path, err = ParsePath("ZZZ{2-1.3=a--b[last]}[-1]")
+	expected = HL7Path{Segment: "ZZZ", Where: SegmentPredicate{Field: 2, Component: 1, Subcomponent: 3, Value: "a--b[last]"}, SegmentIndex: -1}
+	expectValue(t, expected, path, err)
+	path, err = ParsePath("OBX{1=}")
+	expectValue(t, HL7Path{Segment: "OBX", Where: SegmentPredicate{Field: 1}, SegmentIndex: 1}, path, err)
+
+	_, err = ParsePath("OBX{3.2=Body Weight-5")
+	expectError(t, err, "invalid path format: the segment predicate has no closing }")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	_, err = ParsePath("OBX{3.2}-5")
+	expectError(t, err, `invalid path format: segment predicate "{3.2}" must be like {FIELD.COMPONENT.SUBCOMPONENT=VALUE}`)
+	_, err = ParsePath("OBX{3.*=X}")
+	expectError(t, err, `invalid path format: segment predicate "{3.*=X}" must be like {FIELD.COMPONENT.SUBCOMPONENT=VALUE}`)
+	_, err = ParsePath("OBX[2]{3=X}")
+	expectError(t, err, "invalid path format")
+
+	// the other parsers read it too
+	path, err = ParsePathSep("OBX{3.2=a/b}/5", '/')
+	expectValue(t, MustParsePath("OBX{3.2=a/b}-5"), path, err)
+	path, rest, err := ParsePathPrefix("OBX{3.2=Body Weight}-5:none")
+	expectValue(t, MustParsePath("OBX{3.2=Body Weight}-5"), path, err)
+	expectValue(t, ":none", rest)
+
+	err = HL7Path{Segment: "OBX", Where: SegmentPredicate{Value: "X"}, SegmentIndex: 1}.Validate()
+	expectError(t, err, "if Where is set, Where.Field must be at least 1 and its component and subcomponent can't be negative")
+	err = HL7Path{Segment: "OBX", Where: SegmentPredicate{Field: 3, Subcomponent: 1}, SegmentIndex: 1}.Validate()
+	expectError(t, err, "if Where.Subcomponent is set, Where.Component must be set")
+	err = HL7Path{Segment: "OBX", Where: SegmentPredicate{Field: 3, Value: "}"}, SegmentIndex: 1}.Validate()
+	expectError(t, err, "Where.Value can't contain { or }")
+	err = HL7Path{Where: SegmentPredicate{Field: 3}}.Validate()
+	expectError(t, err, "if Segment is empty, the rest of the path must be empty or 0")
+}
+
+func TestHL7PathJSON(t *testing.T) {
+	// a path without a predicate has no where
+	data, err := json.Marshal(MustParsePath("PID-5.1"))
+	expectValue(t, `{"segment":"PID","segment_index":1,"field":5,"repetition_index":1,"component":1}`, string(data), err)
+	data, err = json.Marshal(HL7Path{})
+	expectValue(t, `{"segment":"","segment_index":0}`, string(data), err)
+
+	path := MustParsePath("OBX{3.2=Body Weight}[2]-5")
+	data, err = json.Marshal(path)
+	expectValue(t, `{"segment":"OBX","segment_index":2,"field":5,"repetition_index":1,"where":{"field":3,"component":2,"value":"Body Weight"}}`, string(data), err)
+	var decoded HL7Path
+	expectValue(t, path, decoded, json.Unmarshal(data, &decoded))
+
+	// the same behind a pointer
+	data, err = json.Marshal([]*HL7Path{&path})
+	expectValue(t, `[{"segment":"OBX","segment_index":2,"field":5,"repetition_index":1,"where":{"field":3,"component":2,"value":"Body Weight"}}]`, string(data), err)
+}
+
+func TestHL7PathString(t *testing.T) {
+	expectValue(t, "PID[2]-3[4].5.6", HL7Path{Segment: "PID", SegmentIndex: 2, Field: 3, RepetitionIndex: 4, Component: 5, Subcomponent: 6}.String())
+	expectValue(t, "PID-3", HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: 1}.String())
+	expectValue(t, "MSH-1", HL7Path{Segment: "MSH", SegmentIndex: 1, Field: 1, RepetitionIndex: 1}.String())
+	expectValue(t, "", HL7Path{}.String())
+
+	// every valid path survives a round trip, whichever separators it was
+	// written with
+	for _, p := range []string{
+		"", "PID", "OBX[2]", "MSH-1", "MSH-2", "MSH.9.2", "PID-3[2]", "PID-3[*].1",
+		"PID[1]-5[2].3", "OBX[2].5.2", "ZZZ-2[2].2.3", "PV1-3-2-1", "OBX[-1]-5[-2]",
+		"OBX{3.2=Body Weight}-5", "OBX{3=^Body Weight}[-1]", "OBX{3.1.2=}", "ZZZ{4=a-b.c[1]}-2",
+	} {
+		path, err1 := ParsePath(p)
+		again, err2 := ParsePath(path.String())
+		expectValue(t, path, again, err1, err2)
+	}
+}
+
+func TestHL7PathStringWith(t *testing.T) {
+	path := HL7Path{Segment: "PID", SegmentIndex: 2, Field: 3, RepetitionIndex: 4, Component: 5, Subcomponent: 6}
+	expectValue(t, "PID[2]-3[4].5.6", path.StringWith(HyphenStyle))
+	expectValue(t, "PID[2].3[4].5.6", path.StringWith(DotStyle))
+	expectValue(t, path.String(), path.StringWith(HyphenStyle))
+	expectValue(t, "PID", MustParsePath("PID").StringWith(DotStyle))
+	expectValue(t, "", HL7Path{}.StringWith(DotStyle))
+	expectValue(t, "MSH.9.2", MustParsePath("MSH-9.2").StringWith(DotStyle))
+	expectValue(t, "PID-9.2", MustParsePath("PID.9.2").StringWith(HyphenStyle))
+
+	for _, p := range []string{
+		"", "PID", "OBX[2]", "MSH-1", "MSH.9.2", "PID-3[*].1", "PID-5.*", "PID-3.4.*",
+		"OBX[-1]-5[-2]", "ZZZ-2[2].2.3", "OBX{3.2=Body Weight}-5",
+	} {
+		for _, style := range []PathStyle{HyphenStyle, DotStyle} {
+			path, err1 := ParsePath(p)
+			again, err2 := ParsePath(path.StringWith(style))
+			expectValue(t, path, again, err1, err2)
+		}
+	}
+}
+
+func TestMustParsePath(t *testing.T) {
+	expectValue(t, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3, RepetitionIndex: 2, Component: 1}, MustParsePath("PID-3[2].1"))
+
+	defer func() {
+		expectValue(t, `hl7: ParsePath("PID-3.1.2.3"): invalid path format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT`, recover())
+	}()
+	MustParsePath("PID-3.1.2.3")
+}
+
+func TestParsePathWildcardComponent(t *testing.T) {
+	path, err := ParsePath("PID-5[2].*")
+	expectValue(t, HL7Path{
+		Segment:         "PID",
+		SegmentIndex:    1,
+		Field:           5,
+		RepetitionIndex: 2,
+		Component:       WildcardComponent,
+	}, path, err)
+	expectValue(t, "PID-5[2].*", path.String())
+
+	// these parse but are not valid
+	path, err = ParsePath("PID-5.*.1")
+	expectValue(t, nil, err)
+	expectError(t, path.Validate(), "if Component is a wildcard, Subcomponent must be empty or 0")
+
+	path, err = ParsePath("PID-5[*].*")
+	expectValue(t, nil, err)
+	expectError(t, path.Validate(), "a path can only have one wildcard")
+
+	_, err = ParsePath("PID-*")
+	expectError(t, err, "invalid path format")
+
+	_, err = ParsePath("PID-5.*.1.2")
+	expectError(t, err, "invalid path format: too many levels, a path can't go deeper than SEGMENT-FIELD.COMPONENT.SUBCOMPONENT")
+}
+
+func TestParsePathWildcardSubcomponent(t *testing.T) {
+	path, err := ParsePath("PID-3[1].4.*")
+	expectValue(t, HL7Path{
+		Segment:         "PID",
+		SegmentIndex:    1,
+		Field:           3,
+		RepetitionIndex: 1,
+		Component:       4,
+		Subcomponent:    WildcardSubcomponent,
+	}, path, err)
+	expectValue(t, "PID-3.4.*", path.String())
+
+	path, err = ParsePath("PID-3[*].4.*")
+	expectValue(t, nil, err)
+	expectError(t, path.Validate(), "a path can only have one wildcard")
+
+	path, err = ParsePath("PID-3.*.*")
+	expectValue(t, nil, err)
+	expectError(t, path.Validate(), "if Component is a wildcard, Subcomponent must be empty or 0")
+}
+
+func TestHL7PathValidateAll(t *testing.T) {
+	expectValue(t, 0, len(MustParsePath("PID-5.1").ValidateAll()))
+	expectValue(t, 0, len(HL7Path{}.ValidateAll()))
+
+	// every broken rule is reported, Validate reports the first
+	path := HL7Path{Segment: "MSH", SegmentIndex: 2, Field: 1, Component: 1, Subcomponent: 1}
+	errs := path.ValidateAll()
+	expectValue(t, 3, len(errs))
+	expectError(t, errs[0], "if Segment is MSH, SegmentIndex must be 1")
+	expectError(t, errs[1], "if Segment is MSH and Field is 1, the rest of the path must be empty or 0")
+	expectError(t, errs[2], "if Field is set, RepetitionIndex must be at least 1")
+	expectError(t, path.Validate(), "if Segment is MSH, SegmentIndex must be 1")
+
+	path = HL7Path{Segment: "PID", SegmentIndex: 1, RepetitionIndex: 2, Component: WildcardComponent, Subcomponent: 1}
+	errs = path.ValidateAll()
+	expectValue(t, 3, len(errs))
+	expectError(t, errs[0], "if RepetitionIndex is set, Field must be set")
+	expectError(t, errs[1], "if Component is set, Field must be set")
+	expectError(t, errs[2], "if Component is a wildcard, Subcomponent must be empty or 0")
+	for _, err := range errs {
+		expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	}
+
+	// an empty segment is the one rule
+	errs = HL7Path{Field: 1, Component: 1}.ValidateAll()
+	expectValue(t, 1, len(errs))
+	expectError(t, errs[0], "if Segment is empty, the rest of the path must be empty or 0")
+}
+
+func TestParsePathSep(t *testing.T) {
+	path, err := ParsePathSep("PID/5/1", '/')
+	expectValue(t, MustParsePath("PID-5.1"), path, err)
+	path, err = ParsePathSep("OBX[-1]/5[2]", '/')
+	expectValue(t, MustParsePath("OBX[-1]-5[2]"), path, err)
+	path, err = ParsePathSep("PID:3[*]|1", ':', '|')
+	expectValue(t, MustParsePath("PID-3[*].1"), path, err)
+
+	// - and . still work, alone or mixed in
+	path, err = ParsePathSep("PID-5/1", '/')
+	expectValue(t, MustParsePath("PID-5.1"), path, err)
+	path, err = ParsePathSep("PID-5.1")
+	expectValue(t, MustParsePath("PID-5.1"), path, err)
+
+	_, err = ParsePathSep("PID/5/1")
+	expectError(t, err, "invalid path format")
+	_, err = ParsePathSep("PID151", '1')
+	expectError(t, err, "invalid path separator '1': separators can't be letters, digits, *, square brackets, braces or =")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	_, err = ParsePathSep("PID[5", '[')
+	expectError(t, err, "invalid path separator '[': separators can't be letters, digits, *, square brackets, braces or =")
+	// letters would break the [first] and [last] aliases and segment names
+	_, err = ParsePathSep("PID-3[last]", 't')
+	expectError(t, err, "invalid path separator 't': separators can't be letters, digits, *, square brackets, braces or =")
+	_, err = ParsePathSep("PIDé3", 'é')
+	expectError(t, err, "invalid path separator 'é': separators can't be letters, digits, *, square brackets, braces or =")
+	// and braces or = a segment predicate
+	for _, sep := range "{}=" {
+		_, err = ParsePathSep("OBX{3=a}-5", sep)
+		expectError(t, err, fmt.Sprintf("invalid path separator %q: separators can't be letters, digits, *, square brackets, braces or =", sep))
+	}
+}
+
+func TestParsePathPrefix(t *testing.T) {
+	path, rest, err := ParsePathPrefix("PID-8:unknown")
+	expectValue(t, MustParsePath("PID-8"), path, err)
+	expectValue(t, ":unknown", rest)
+
+	for p, expected := range map[string]string{
+		"PID-8":                 "",
+		"OBX[-1]-5[2] == 80":    " == 80",
+		"PID-3[*].1,PID-3[*].4": ",PID-3[*].4",
+		"OBX[last]-5[first]}":   "}",
+		"PID-5.*.1":             "",
+		"MSH-9.1-9.2":           ".2",
+		"PID-3.1.2.3":           ".3",
+		"PID-3[x]":              "[x]",
+		"PIDX-1":                "X-1",
+		"ZZZ-2 ":                " ",
+	} {
+		_, rest, err := ParsePathPrefix(p)
+		expectValue(t, expected, rest, err)
+	}
+
+	// the prefix parses the same as ParsePath
+	path, rest, err = ParsePathPrefix("OBX[last]-5[first].2|default")
+	expectValue(t, MustParsePath("OBX[-1]-5[1].2"), path, err)
+	expectValue(t, "|default", rest)
+
+	path, rest, err = ParsePathPrefix("")
+	expectValue(t, HL7Path{}, path, err)
+	expectValue(t, "", rest)
+
+	_, rest, err = ParsePathPrefix(":unknown")
+	expectError(t, err, `invalid path format: ":unknown" does not start with a path`)
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	expectValue(t, ":unknown", rest)
+	_, _, err = ParsePathPrefix("pid-8")
+	expectError(t, err, `invalid path format: "pid-8" does not start with a path`)
+
+	// ParsePath still wants all of it
+	_, err = ParsePath("PID-8:unknown")
+	expectError(t, err, "invalid path format")
+}
+
+func TestValidatePathForMessage(t *testing.T) {
+	for _, p := range []string{"", "MSH", "MSH-1", "MSH-2", "MSH-2[*]", "MSH-2[-1]", "MSH-9.2", "PID-5.1", "OBX[9]-5", "ZZZ-2[2].2.3"} {
+		expectValue(t, nil, ValidatePathForMessage(message, MustParsePath(p)))
+	}
+
+	// the encoding characters have no structure
+	err := ValidatePathForMessage(message, MustParsePath("MSH-2.3"))
+	expectError(t, err, "MSH-2 holds the encoding characters, it has no components")
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	err = ValidatePathForMessage(message, MustParsePath("MSH-2.*"))
+	expectError(t, err, "MSH-2 holds the encoding characters, it has no components")
+	err = ValidatePathForMessage(message, MustParsePath("MSH-2[2]"))
+	expectError(t, err, "MSH-2 holds the encoding characters, it has no repetition 2")
+	err = ValidatePathForMessage(message, MustParsePath("MSH-1[-2]"))
+	expectError(t, err, "MSH-1 holds the encoding characters, it has no repetition -2")
+
+	// and the rules of Validate still apply
+	err = ValidatePathForMessage(message, MustParsePath("MSH-1.1"))
+	expectError(t, err, "if Segment is MSH and Field is 1, the rest of the path must be empty or 0")
+	err = ValidatePathForMessage(message, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 3})
+	expectError(t, err, "if Field is set, RepetitionIndex must be at least 1")
+
+	err = ValidatePathForMessage("PID|1", MustParsePath("PID-1"))
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+}
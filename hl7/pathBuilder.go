@@ -0,0 +1,73 @@
+package hl7
+
+// PathBuilder builds an HL7Path one level at a time, e.g.
+// NewPath("PID").Index(2).Field(3).Rep(4).Component(5).Sub(6).Build(). Indexes
+// that aren't set default like they do in ParsePath: the segment index to 1
+// and the repetition index to 1 once there is a field.
+type PathBuilder struct {
+	path   HL7Path
+	repSet bool
+}
+
+// NewPath starts a path to the first segment named segment.
+func NewPath(segment string) *PathBuilder {
+	return &PathBuilder{path: HL7Path{Segment: segment, SegmentIndex: 1}}
+}
+
+// Index sets the segment index, negative counts from the end.
+func (b *PathBuilder) Index(index int) *PathBuilder {
+	b.path.SegmentIndex = index
+	return b
+}
+
+// Field sets the field.
+func (b *PathBuilder) Field(field int) *PathBuilder {
+	b.path.Field = field
+	return b
+}
+
+// Rep sets the repetition index, negative counts from the end and
+// WildcardRepetition selects all of them.
+func (b *PathBuilder) Rep(index int) *PathBuilder {
+	b.path.RepetitionIndex = index
+	b.repSet = true
+	return b
+}
+
+// Component sets the component, WildcardComponent selects all of them.
+func (b *PathBuilder) Component(component int) *PathBuilder {
+	b.path.Component = component
+	return b
+}
+
+// Sub sets the subcomponent, WildcardSubcomponent selects all of them.
+func (b *PathBuilder) Sub(subcomponent int) *PathBuilder {
+	b.path.Subcomponent = subcomponent
+	return b
+}
+
+// Build returns the path, or an error matching ErrInvalidPath if the segment
+// name isn't valid or the levels don't fit together, like a component
+// without a field.
+func (b *PathBuilder) Build() (HL7Path, error) {
+	path := b.path
+	if _, err := parseSegmentNameOrError(path.Segment); err != nil {
+		return HL7Path{}, &kindError{ErrInvalidPath, err}
+	}
+	if !b.repSet && path.Field > 0 {
+		path.RepetitionIndex = 1
+	}
+	if err := path.Validate(); err != nil {
+		return HL7Path{}, err
+	}
+	return path, nil
+}
+
+// MustBuild is like Build but panics if the path is invalid.
+func (b *PathBuilder) MustBuild() HL7Path {
+	path, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
@@ -0,0 +1,44 @@
+package hl7
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPath(t *testing.T) {
+	path, err := NewPath("PID").Index(2).Field(3).Rep(4).Component(5).Sub(6).Build()
+	expectValue(t, HL7Path{Segment: "PID", SegmentIndex: 2, Field: 3, RepetitionIndex: 4, Component: 5, Subcomponent: 6}, path, err)
+
+	// indexes default like they do in ParsePath
+	for p, b := range map[string]*PathBuilder{
+		"PV1":        NewPath("PV1"),
+		"PV1-3":      NewPath("PV1").Field(3),
+		"PV1-3.2":    NewPath("PV1").Field(3).Component(2),
+		"OBX[-1]-5":  NewPath("OBX").Index(-1).Field(5),
+		"PID-3[*].1": NewPath("PID").Field(3).Rep(WildcardRepetition).Component(1),
+		"MSH-9.2":    NewPath("MSH").Field(9).Component(2),
+	} {
+		expected, err1 := ParsePath(p)
+		path, err2 := b.Build()
+		expectValue(t, expected, path, err1, err2)
+	}
+
+	_, err = NewPath("pid").Field(3).Build()
+	expectError(t, err, "segment name must begin with an uppercase letter")
+	_, err = NewPath("PID").Component(1).Build()
+	expectError(t, err, "if Component is set, Field must be set")
+	_, err = NewPath("PID").Field(3).Sub(1).Build()
+	expectError(t, err, "if Subcomponent is set, Component must be set")
+	_, err = NewPath("MSH").Index(2).Build()
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+}
+
+func TestMustBuild(t *testing.T) {
+	expectValue(t, HL7Path{Segment: "PID", SegmentIndex: 1, Field: 5, RepetitionIndex: 1}, NewPath("PID").Field(5).MustBuild())
+
+	defer func() {
+		err, _ := recover().(error)
+		expectError(t, err, "if RepetitionIndex is set, Field must be set")
+	}()
+	NewPath("PID").Rep(2).MustBuild()
+}
@@ -0,0 +1,123 @@
+package hl7
+
+import "strings"
+
+// DefaultPHIPaths are the fields RedactDefaultPHI redacts, the PID fields that
+// identify a patient: identifiers (PID-3), name (PID-5), mother's maiden name
+// (PID-6), date of birth (PID-7), address (PID-11), phone numbers (PID-13 and
+// PID-14) and SSN (PID-19), every repetition of them.
+var DefaultPHIPaths = []HL7Path{
+	MustParsePath("PID-3[*]"),
+	MustParsePath("PID-5[*]"),
+	MustParsePath("PID-6[*]"),
+	MustParsePath("PID-7[*]"),
+	MustParsePath("PID-11[*]"),
+	MustParsePath("PID-13[*]"),
+	MustParsePath("PID-14[*]"),
+	MustParsePath("PID-19[*]"),
+}
+
+// Redact returns a copy of message with every populated value at paths
+// replaced by replacement, for sharing messages without the data in them.
+// The structure is kept: each non-empty subcomponent, component, repetition
+// or field below a path is replaced on its own and empty ones stay empty, so
+// PID-5 of DOE^JANE^^^^^L becomes XXX^XXX^^^^^XXX. A path without a field
+// redacts every field of the segment, the encoding characters of MSH are
+// kept. A wildcard path redacts everything it selects. Paths the message
+// doesn't reach are skipped, nothing is added. The replacement is escaped.
+func Redact(message string, paths []HL7Path, replacement string) (string, error) {
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return "", err
+	}
+	replacement = Escape(replacement, sep)
+	for _, path := range paths {
+		// every component is the repetition, every subcomponent the component
+		if path.Component == WildcardComponent {
+			path.Component, path.Subcomponent = 0, 0
+		}
+		if path.Subcomponent == WildcardSubcomponent {
+			path.Subcomponent = 0
+		}
+		targets := []HL7Path{path}
+		if path.RepetitionIndex == WildcardRepetition {
+			count, err := RepetitionCount(message, path)
+			if err != nil {
+				return "", err
+			}
+			targets = targets[:0]
+			for r := 1; r <= count; r++ {
+				target := path
+				target.RepetitionIndex = r
+				targets = append(targets, target)
+			}
+		}
+		for _, target := range targets {
+			if message, err = redactPath(message, target, replacement, sep); err != nil {
+				return "", err
+			}
+		}
+	}
+	return message, nil
+}
+
+// RedactDefaultPHI redacts the DefaultPHIPaths of message with replacement,
+// see Redact.
+func RedactDefaultPHI(message string, replacement string) (string, error) {
+	return Redact(message, DefaultPHIPaths, replacement)
+}
+
+func redactPath(message string, path HL7Path, replacement string, sep Encoding) (string, error) {
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		// the encoding characters are not data
+		return message, nil
+	}
+	value, err := AbstractHL7(message, path)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return message, nil
+	}
+	var redacted string
+	switch {
+	case path.Field == 0:
+		fields := strings.Split(value, string(sep.Field))
+		for i := 1; i < len(fields); i++ {
+			// fields[1] of MSH is MSH-2
+			if path.Segment != "MSH" || i > 1 {
+				fields[i] = redactPieces(fields[i], []byte{sep.Repetition, sep.Component, sep.Subcomponent}, replacement)
+			}
+		}
+		redacted = strings.Join(fields, string(sep.Field))
+	case path.Component == 0:
+		redacted = redactPieces(value, []byte{sep.Component, sep.Subcomponent}, replacement)
+	case path.Subcomponent == 0:
+		redacted = redactPieces(value, []byte{sep.Subcomponent}, replacement)
+	default:
+		redacted = replacement
+	}
+	return editSegment(message, path, func(segment string, sep Encoding) (string, error) {
+		return setRawInSegment(segment, path, redacted, sep)
+	})
+}
+
+// redactPieces replaces every non-empty piece of value between separators
+// with replacement.
+func redactPieces(value string, separators []byte, replacement string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(value); i++ {
+		if i < len(value) && strings.IndexByte(string(separators), value[i]) == -1 {
+			continue
+		}
+		if i > start {
+			b.WriteString(replacement)
+		}
+		if i < len(value) {
+			b.WriteByte(value[i])
+		}
+		start = i + 1
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package hl7
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	msg := "MSH|^~\\&|HIS|RIH\rPID|1||123^^^^MRN~456^^^^SSN||DOE^JANE^^^^^L||19610615\rNK1|1|DOE^JOHN|SPO\r"
+
+	// each populated piece is replaced, empty ones stay empty
+	resp, err := Redact(msg, []HL7Path{MustParsePath("PID-5")}, "XXX")
+	expectValue(t, "MSH|^~\\&|HIS|RIH\rPID|1||123^^^^MRN~456^^^^SSN||XXX^XXX^^^^^XXX||19610615\rNK1|1|DOE^JOHN|SPO\r", resp, err)
+
+	// one repetition or all of them, one component or one subcomponent
+	resp, err = Redact(msg, []HL7Path{MustParsePath("PID-3[2]")}, "XXX")
+	expectValue(t, "MSH|^~\\&|HIS|RIH\rPID|1||123^^^^MRN~XXX^^^^XXX||DOE^JANE^^^^^L||19610615\rNK1|1|DOE^JOHN|SPO\r", resp, err)
+	resp, err = Redact(msg, []HL7Path{MustParsePath("PID-3[*].1"), MustParsePath("PID-5.2")}, "XXX")
+	expectValue(t, "MSH|^~\\&|HIS|RIH\rPID|1||XXX^^^^MRN~XXX^^^^SSN||DOE^XXX^^^^^L||19610615\rNK1|1|DOE^JOHN|SPO\r", resp, err)
+	resp, err = Redact("MSH|^~\\&|HIS\rPID|1|A&B&&C", []HL7Path{MustParsePath("PID-2.1.2")}, "XXX")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A&XXX&&C", resp, err)
+
+	// a whole segment, MSH keeps its encoding characters
+	resp, err = Redact(msg, []HL7Path{MustParsePath("NK1"), MustParsePath("MSH")}, "XXX")
+	expectValue(t, "MSH|^~\\&|XXX|XXX\rPID|1||123^^^^MRN~456^^^^SSN||DOE^JANE^^^^^L||19610615\rNK1|XXX|XXX^XXX|XXX\r", resp, err)
+
+	// the replacement is escaped, missing values are not added
+	resp, err = Redact(msg, []HL7Path{MustParsePath("PID-7"), MustParsePath("PID-8"), MustParsePath("OBX-5"), MustParsePath("MSH-2")}, "^|")
+	expectValue(t, "MSH|^~\\&|HIS|RIH\rPID|1||123^^^^MRN~456^^^^SSN||DOE^JANE^^^^^L||\\S\\\\F\\\rNK1|1|DOE^JOHN|SPO\r", resp, err)
+	resp, err = Redact(msg, nil, "XXX")
+	expectValue(t, msg, resp, err)
+
+	_, err = Redact(msg, []HL7Path{{Segment: "MSH", SegmentIndex: 2}}, "XXX")
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+	_, err = Redact("PID|1", DefaultPHIPaths, "XXX")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestRedactDefaultPHI(t *testing.T) {
+	resp, err := RedactDefaultPHI(message, "X")
+	expectValue(t, "MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5\rPID|||X^^^^X~X^^^^X||X^X^X^^^^X~X^X^^^^^X||X|F||C|X^^X^X^X||X|X||S||555-55-5555\rPV1||I|2000^2012^01||||004777^LEBAUER^JAMES^A^^^^MD|||||||||||V\rOBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F\rOBX|2|ST|^Body Weight||79|kg|50-100|N|||F\rZZZ||This is~a^custom&segment&with^custom&fields\rZZZ||foo|bar|baz", resp, err)
+}
@@ -0,0 +1,119 @@
+package hl7
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Unbounded can be used as a FieldSchema Max to allow any number of
+// repetitions.
+const Unbounded = -1
+
+// Schema describes the segments of a message definition along with the
+// cardinality and data type of each of their fields. A schema is loaded from
+// JSON shaped like:
+//
+//	{
+//	  "version": "2.5",
+//	  "segments": {
+//	    "PID": {
+//	      "fields": [
+//	        {"name": "Set ID", "data_type": "SI"},
+//	        {"name": "Patient ID", "data_type": "CX"},
+//	        {"name": "Patient Identifier List", "data_type": "CX", "min": 1, "max": -1}
+//	      ]
+//	    }
+//	  }
+//	}
+//
+// Fields are listed in order, so the first entry describes field 1.
+type Schema struct {
+	Version  string                   `json:"version,omitempty"`
+	Segments map[string]SegmentSchema `json:"segments"`
+}
+
+type SegmentSchema struct {
+	Name   string        `json:"name,omitempty"`
+	Fields []FieldSchema `json:"fields"`
+}
+
+type FieldSchema struct {
+	Name     string `json:"name,omitempty"`
+	DataType string `json:"data_type"`
+	// Min is the minimum number of repetitions, anything above 0 makes the
+	// field required.
+	Min int `json:"min,omitempty"`
+	// Max is the maximum number of repetitions. If omitted it defaults to 1,
+	// use Unbounded (-1) to allow any number of repetitions.
+	Max int `json:"max,omitempty"`
+}
+
+// knownDataTypes are the HL7 v2 data types a schema field may declare.
+var knownDataTypes = map[string]bool{
+	"AD": true, "CE": true, "CF": true, "CK": true, "CM": true, "CN": true,
+	"CNE": true, "CP": true, "CQ": true, "CWE": true, "CX": true, "DLN": true,
+	"DR": true, "DT": true, "DTM": true, "ED": true, "EI": true, "EIP": true,
+	"FC": true, "FN": true, "FT": true, "HD": true, "ID": true, "IS": true,
+	"JCC": true, "MA": true, "MO": true, "MSG": true, "NA": true, "NM": true,
+	"PL": true, "PN": true, "PT": true, "RI": true, "RP": true, "SAD": true,
+	"SI": true, "SN": true, "SPS": true, "ST": true, "TM": true, "TN": true,
+	"TQ": true, "TS": true, "TX": true, "VARIES": true, "VID": true,
+	"XAD": true, "XCN": true, "XON": true, "XPN": true, "XTN": true,
+}
+
+// LoadSchema decodes a JSON schema definition and validates it.
+func LoadSchema(data []byte) (*Schema, error) {
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Field returns the definition of a field (1-based) of a segment, if the
+// schema describes it.
+func (s *Schema) Field(segment string, field int) (FieldSchema, bool) {
+	seg, ok := s.Segments[segment]
+	if !ok || field < 1 || field > len(seg.Fields) {
+		return FieldSchema{}, false
+	}
+	return seg.Fields[field-1], true
+}
+
+func (s *Schema) validate() error {
+	if len(s.Segments) == 0 {
+		return fmt.Errorf("invalid schema: no segments defined")
+	}
+	for key, seg := range s.Segments {
+		if _, err := parseSegmentNameOrError(key); err != nil {
+			return fmt.Errorf("invalid schema: segment %q: %w", key, err)
+		}
+		// the name is optional, but if it is given it must agree with the key
+		if seg.Name == "" {
+			seg.Name = key
+		} else if seg.Name != key {
+			return fmt.Errorf("invalid schema: segment %q is named %q", key, seg.Name)
+		}
+		for i := range seg.Fields {
+			field := &seg.Fields[i]
+			if !knownDataTypes[field.DataType] {
+				return fmt.Errorf("invalid schema: %s-%d: unknown data type %q", key, i+1, field.DataType)
+			}
+			if field.Min < 0 {
+				return fmt.Errorf("invalid schema: %s-%d: min must not be negative", key, i+1)
+			}
+			// default to a non-repeating field
+			if field.Max == 0 {
+				field.Max = 1
+			}
+			if field.Max != Unbounded && (field.Max < 1 || field.Max < field.Min) {
+				return fmt.Errorf("invalid schema: %s-%d: max must be at least 1 and not less than min", key, i+1)
+			}
+		}
+		s.Segments[key] = seg
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package hl7
+
+import "testing"
+
+var schemaJSON = []byte(`{
+	"version": "2.5",
+	"segments": {
+		"PID": {
+			"fields": [
+				{"name": "Set ID", "data_type": "SI"},
+				{"name": "Patient ID", "data_type": "CX"},
+				{"name": "Patient Identifier List", "data_type": "CX", "min": 1, "max": -1},
+				{"name": "Alternate Patient ID", "data_type": "CX"},
+				{"name": "Patient Name", "data_type": "XPN", "min": 1, "max": -1}
+			]
+		}
+	}
+}`)
+
+func TestLoadSchema(t *testing.T) {
+	schema, err := LoadSchema(schemaJSON)
+	expectValue(t, "2.5", schema.Version, err)
+	expectValue(t, "PID", schema.Segments["PID"].Name)
+
+	field, ok := schema.Field("PID", 3)
+	expectValue(t, true, ok)
+	expectValue(t, FieldSchema{Name: "Patient Identifier List", DataType: "CX", Min: 1, Max: Unbounded}, field)
+
+	// max defaults to a single occurrence
+	field, _ = schema.Field("PID", 1)
+	expectValue(t, 1, field.Max)
+
+	_, ok = schema.Field("PID", 6)
+	expectValue(t, false, ok)
+	_, ok = schema.Field("PV1", 1)
+	expectValue(t, false, ok)
+}
+
+func TestLoadSchemaInvalid(t *testing.T) {
+	_, err := LoadSchema([]byte(`{"segments": `))
+	expectError(t, err)
+
+	_, err = LoadSchema([]byte(`{"segments": {}}`))
+	expectError(t, err, "invalid schema: no segments defined")
+
+	_, err = LoadSchema([]byte(`{"segments": {"pid": {"fields": []}}}`))
+	expectError(t, err, `invalid schema: segment "pid": segment name must begin with an uppercase letter`)
+
+	_, err = LoadSchema([]byte(`{"segments": {"PID": {"name": "PV1", "fields": []}}}`))
+	expectError(t, err, `invalid schema: segment "PID" is named "PV1"`)
+
+	_, err = LoadSchema([]byte(`{"segments": {"PID": {"fields": [{"data_type": "XX"}]}}}`))
+	expectError(t, err, `invalid schema: PID-1: unknown data type "XX"`)
+
+	_, err = LoadSchema([]byte(`{"segments": {"PID": {"fields": [{"data_type": "ST", "min": -1}]}}}`))
+	expectError(t, err, "invalid schema: PID-1: min must not be negative")
+
+	_, err = LoadSchema([]byte(`{"segments": {"PID": {"fields": [{"data_type": "ST", "min": 2, "max": 1}]}}}`))
+	expectError(t, err, "invalid schema: PID-1: max must be at least 1 and not less than min")
+}
@@ -0,0 +1,30 @@
+package hl7
+
+// DetectSegmentSeparator returns the segment terminator the message uses the
+// most, \r, \n or \r\n, or the one that comes first when they are used as
+// often. A message with a single unterminated segment has none and gets \r,
+// the HL7 segment terminator.
+func DetectSegmentSeparator(message string) (string, error) {
+	if _, err := ParseEncoding(message); err != nil {
+		return "", err
+	}
+	_, terminators := splitSegmentsKeepingTerminators(message)
+	return dominantTerminator(terminators), nil
+}
+
+// dominantTerminator returns the terminator used the most, the first one on
+// a tie, or \r if there are none.
+func dominantTerminator(terminators []string) string {
+	counts := map[string]int{}
+	dominant := "\r"
+	for _, terminator := range terminators {
+		if terminator == "" {
+			continue
+		}
+		counts[terminator]++
+		if counts[terminator] > counts[dominant] {
+			dominant = terminator
+		}
+	}
+	return dominant
+}
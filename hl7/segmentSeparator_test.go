@@ -0,0 +1,22 @@
+package hl7
+
+import "testing"
+
+func TestDetectSegmentSeparator(t *testing.T) {
+	for msg, expected := range map[string]string{
+		message:                              "\r",
+		"MSH|^~\\&|HIS\nPID|1\nPV1|1\n":      "\n",
+		"MSH|^~\\&|HIS\r\nPID|1\r\nPV1|1":    "\r\n",
+		"MSH|^~\\&|HIS\nPID|1\r\nPV1|1\r\n":  "\r\n",
+		"MSH|^~\\&|HIS\nPID|1\rPV1|1":        "\n",
+		"MSH|^~\\&|HIS":                      "\r",
+		"MSH|^~\\&|HIS\n":                    "\n",
+		"MSH|^~\\&|HIS\rPID|1\\X0A\\\nPV1|1": "\r",
+	} {
+		sep, err := DetectSegmentSeparator(msg)
+		expectValue(t, expected, sep, err)
+	}
+
+	_, err := DetectSegmentSeparator("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
@@ -0,0 +1,186 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AbstractHL7Segments returns the full text of every segment named segment,
+// in the order they appear in the message.
+func AbstractHL7Segments(message string, segment string) ([]string, error) {
+	if _, err := parseSegmentNameOrError(segment); err != nil {
+		return nil, err
+	}
+	return SegmentsOfTypes(message, segment)
+}
+
+// CountSegments returns how many segments are named segment, matched the
+// same way AbstractHL7 matches them, so ZZZ doesn't count ZZZ1 segments. A
+// segment the message doesn't have counts 0.
+func CountSegments(message string, segment string) (int, error) {
+	if _, err := parseSegmentNameOrError(segment); err != nil {
+		return 0, err
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return 0, err
+	}
+	return countSelectedSegments(splitByAnyOf(message, []string{"\r\n", "\r", "\n"}), HL7Path{Segment: segment}, sep), nil
+}
+
+// SegmentNames returns the name of every segment in the order they appear in
+// the message, blank lines are skipped.
+func SegmentNames(message string) ([]string, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		if segment == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ForEachSegment calls fn with every segment of the message in order, with
+// its 0-based position in the message, its name and its text as it is in the
+// message, MSH included, without building a Message. Blank lines are skipped.
+// If fn returns an error ForEachSegment stops and returns it.
+func ForEachSegment(message string, fn func(index int, name, raw string) error) error {
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return err
+	}
+	index := 0
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		if segment == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		if err := fn(index, name, segment); err != nil {
+			return err
+		}
+		index++
+	}
+	return nil
+}
+
+// SegmentFields returns the fields of the index-th (1-based, or counted from
+// the end when negative) segment named segment, with the name at index 0 so
+// field numbers line up with indexes: for MSH, fields[1] is MSH-1, the field
+// separator, and fields[2] MSH-2. Empty fields, trailing ones included, are
+// kept. If there is no such segment the error matches ErrSegmentNotFound.
+func SegmentFields(message string, segment string, index int) ([]string, error) {
+	if _, err := parseSegmentNameOrError(segment); err != nil {
+		return nil, err
+	}
+	line, err := AbstractHL7(message, HL7Path{Segment: segment, SegmentIndex: index})
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, &kindError{ErrSegmentNotFound, fmt.Errorf("segment %s[%d] not found", segment, index)}
+	}
+	// the message was already validated by AbstractHL7
+	sep, _ := ParseEncoding(message)
+	fields := strings.Split(line, string(sep.Field))
+	if segment == "MSH" {
+		fields = append(fields[:1], append([]string{string(sep.Field)}, fields[1:]...)...)
+	}
+	return fields, nil
+}
+
+// SegmentsOfTypes returns every segment whose name is one of names, in the
+// order they appear in the message. Names that don't appear simply match
+// nothing.
+func SegmentsOfTypes(message string, names ...string) ([]string, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var res []string
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		if wanted[name] {
+			res = append(res, segment)
+		}
+	}
+	return res, nil
+}
+
+// SegmentAfter returns the first target segment that follows the nth (1-based)
+// anchor segment, e.g. the first OBX after the 2nd OBR. The search is not
+// limited to the anchor's group, it runs to the end of the message. If the
+// anchor or a target after it can't be found an empty string is returned,
+// the same as AbstractHL7 does for missing values.
+func SegmentAfter(message string, anchor string, anchorIndex int, target string) (string, error) {
+	if _, err := parseSegmentNameOrError(anchor); err != nil {
+		return "", err
+	}
+	if _, err := parseSegmentNameOrError(target); err != nil {
+		return "", err
+	}
+	if anchorIndex < 1 {
+		return "", errors.New("anchor index must be at least 1")
+	}
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return "", err
+	}
+	anchors := 0
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		name, _, _ := strings.Cut(segment, string(sep.Field))
+		if anchors == anchorIndex && name == target {
+			return segment, nil
+		}
+		if name == anchor && anchors < anchorIndex {
+			anchors++
+		}
+	}
+	return "", nil
+}
+
+// AbstractHL7BySetID returns field of the first segment named segment whose
+// set ID, field 1, is setID, e.g. OBX-5 of the OBX with set ID 2 rather than
+// of the 2nd OBX. Field 0 returns the whole segment. If no segment has the set
+// ID an empty string is returned, the same as AbstractHL7 does for missing
+// values.
+func AbstractHL7BySetID(message string, segment string, setID string, field int) (string, error) {
+	if _, err := parseSegmentNameOrError(segment); err != nil {
+		return "", err
+	}
+	if setID == "" {
+		return "", errors.New("set ID must not be empty")
+	}
+	path := HL7Path{Segment: segment, SegmentIndex: 1, Field: field}
+	if field > 0 {
+		path.RepetitionIndex = 1
+	}
+	if err := path.Validate(); err != nil {
+		return "", err
+	}
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return "", err
+	}
+	setIDPath := HL7Path{Segment: segment, SegmentIndex: 1, Field: 1, RepetitionIndex: 1}
+	for _, line := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		if !isSegment(line, segment, sep.Field) {
+			continue
+		}
+		// look at the segment on its own so it is the first one named segment
+		if extractFromSegments([]string{line}, setIDPath, sep) == setID {
+			return extractFromSegments([]string{line}, path, sep), nil
+		}
+	}
+	return "", nil
+}
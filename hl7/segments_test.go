@@ -0,0 +1,199 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSegmentsOfTypes(t *testing.T) {
+	segments, err := SegmentsOfTypes(message, "OBX", "PV1")
+	expectDeepValue(t, []string{
+		"PV1||I|2000^2012^01||||004777^LEBAUER^JAMES^A^^^^MD|||||||||||V",
+		"OBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F",
+		"OBX|2|ST|^Body Weight||79|kg|50-100|N|||F",
+	}, segments, err)
+
+	segments, err = SegmentsOfTypes(message, "NTE", "EVN")
+	expectDeepValue(t, []string(nil), segments, err)
+
+	// only whole names match
+	segments, err = SegmentsOfTypes(message, "ZZ", "ZZZZ")
+	expectDeepValue(t, []string(nil), segments, err)
+
+	segments, err = SegmentsOfTypes(message)
+	expectDeepValue(t, []string(nil), segments, err)
+
+	_, err = SegmentsOfTypes("PID|1", "PID")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestSegmentAfter(t *testing.T) {
+	orders := "MSH|^~\\&|LAB|RIH|EKG|EKG|20060529090131||ORU^R01|MSG00002|P|2.5\r" +
+		"PID|||123\r" +
+		"OBR|1||CBC\r" +
+		"OBX|1|NM|WBC||7.2\r" +
+		"OBR|2||BMP\r" +
+		"NTE|1||fasting\r" +
+		"OBX|1|NM|NA||140\r" +
+		"OBX|2|NM|K||4.1\r" +
+		"OBR|3||LIPID"
+
+	segment, err := SegmentAfter(orders, "OBR", 2, "OBX")
+	expectValue(t, "OBX|1|NM|NA||140", segment, err)
+
+	segment, err = SegmentAfter(orders, "OBR", 1, "OBX")
+	expectValue(t, "OBX|1|NM|WBC||7.2", segment, err)
+
+	segment, err = SegmentAfter(orders, "OBR", 1, "NTE")
+	expectValue(t, "NTE|1||fasting", segment, err)
+
+	// nothing follows the last OBR
+	segment, err = SegmentAfter(orders, "OBR", 3, "OBX")
+	expectValue(t, "", segment, err)
+
+	segment, err = SegmentAfter(orders, "OBR", 4, "OBX")
+	expectValue(t, "", segment, err)
+
+	// the anchor and target can be the same type
+	segment, err = SegmentAfter(orders, "OBX", 2, "OBX")
+	expectValue(t, "OBX|2|NM|K||4.1", segment, err)
+
+	_, err = SegmentAfter(orders, "OBR", 0, "OBX")
+	expectError(t, err, "anchor index must be at least 1")
+
+	_, err = SegmentAfter(orders, "obr", 1, "OBX")
+	expectError(t, err, "segment name must begin with an uppercase letter")
+
+	_, err = SegmentAfter("PID|1", "OBR", 1, "OBX")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestAbstractHL7Segments(t *testing.T) {
+	segments, err := AbstractHL7Segments(message, "OBX")
+	expectDeepValue(t, []string{
+		"OBX|1|ST|^Body Height||1.80|m|1.50-2.00|N|||F",
+		"OBX|2|ST|^Body Weight||79|kg|50-100|N|||F",
+	}, segments, err)
+
+	segments, err = AbstractHL7Segments(message, "MSH")
+	expectDeepValue(t, []string{"MSH|^~\\&|HIS|RIH|EKG|EKG|20060529090131||ADT^A01|MSG00001|P|2.5"}, segments, err)
+
+	// custom separators and line endings
+	custom := "MSH*^~\\&*HIS*RIH\r\nOBX*1*ST\nOBX*2*ST\r\nOBXX*3"
+	segments, err = AbstractHL7Segments(custom, "OBX")
+	expectDeepValue(t, []string{"OBX*1*ST", "OBX*2*ST"}, segments, err)
+
+	segments, err = AbstractHL7Segments(message, "NTE")
+	expectDeepValue(t, []string(nil), segments, err)
+
+	_, err = AbstractHL7Segments(message, "OB")
+	expectError(t, err, "segment name must be 3 characters")
+
+	_, err = AbstractHL7Segments("PID|1", "PID")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestCountSegments(t *testing.T) {
+	for name, expected := range map[string]int{"MSH": 1, "PID": 1, "OBX": 2, "ZZZ": 2, "NTE": 0} {
+		count, err := CountSegments(message, name)
+		expectValue(t, expected, count, err)
+	}
+
+	// names are matched whole
+	count, err := CountSegments("MSH|^~\\&|HIS\rZZZ1|a\rZZZ|b\nZZZ\r\nZZ|c", "ZZZ")
+	expectValue(t, 2, count, err)
+
+	_, err = CountSegments(message, "obx")
+	expectError(t, err, "segment name must begin with an uppercase letter")
+
+	_, err = CountSegments("PID|1", "PID")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestSegmentNames(t *testing.T) {
+	names, err := SegmentNames(message)
+	expectDeepValue(t, []string{"MSH", "PID", "PV1", "OBX", "OBX", "ZZZ", "ZZZ"}, names, err)
+
+	// whatever the terminators, blank lines are not segments
+	names, err = SegmentNames("MSH#^~\\&#HIS\r\n\r\nPID#1|2\n\nZPI\r")
+	expectDeepValue(t, []string{"MSH", "PID", "ZPI"}, names, err)
+
+	_, err = SegmentNames("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestSegmentFields(t *testing.T) {
+	fields, err := SegmentFields(message, "PV1", 1)
+	expectDeepValue(t, []string{"PV1", "", "I", "2000^2012^01", "", "", "", "004777^LEBAUER^JAMES^A^^^^MD", "", "", "", "", "", "", "", "", "", "", "V"}, fields, err)
+
+	// MSH-1 is the field separator
+	fields, err = SegmentFields(message, "MSH", 1)
+	expectDeepValue(t, []string{"MSH", "|", "^~\\&", "HIS", "RIH", "EKG", "EKG", "20060529090131", "", "ADT^A01", "MSG00001", "P", "2.5"}, fields, err)
+
+	// trailing empty fields are kept, indexes count from the end too
+	fields, err = SegmentFields("MSH|^~\\&|HIS\rOBX|1||\rOBX|2|ST||", "OBX", -1)
+	expectDeepValue(t, []string{"OBX", "2", "ST", "", ""}, fields, err)
+
+	_, err = SegmentFields(message, "OBX", 3)
+	expectError(t, err, "segment OBX[3] not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+
+	_, err = SegmentFields(message, "MSH", 2)
+	expectError(t, err, "if Segment is MSH, SegmentIndex must be 1")
+
+	_, err = SegmentFields(message, "pv1", 1)
+	expectError(t, err, "segment name must begin with an uppercase letter")
+}
+
+func TestAbstractHL7BySetID(t *testing.T) {
+	resp, err := AbstractHL7BySetID(message, "OBX", "2", 5)
+	expectValue(t, "79", resp, err)
+	resp, err = AbstractHL7BySetID(message, "OBX", "1", 3)
+	expectValue(t, "^Body Height", resp, err)
+	resp, err = AbstractHL7BySetID(message, "OBX", "2", 0)
+	expectValue(t, "OBX|2|ST|^Body Weight||79|kg|50-100|N|||F", resp, err)
+
+	// the set ID is matched, not the position
+	reordered := "MSH|^~\\&|HIS\rOBX|2||||79\rOBX|1||||1.80"
+	resp, err = AbstractHL7BySetID(reordered, "OBX", "1", 5)
+	expectValue(t, "1.80", resp, err)
+
+	// a set ID that is not there is an empty value
+	resp, err = AbstractHL7BySetID(message, "OBX", "3", 5)
+	expectValue(t, "", resp, err)
+
+	_, err = AbstractHL7BySetID(message, "OBX", "", 5)
+	expectError(t, err, "set ID must not be empty")
+	_, err = AbstractHL7BySetID(message, "obx", "2", 5)
+	expectError(t, err, "segment name must begin with an uppercase letter")
+	_, err = AbstractHL7BySetID(message, "OBX", "2", -1)
+	expectValue(t, true, errors.Is(err, ErrInvalidPath))
+	_, err = AbstractHL7BySetID("PID|1", "OBX", "2", 5)
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestForEachSegment(t *testing.T) {
+	var visited []string
+	err := ForEachSegment("MSH|^~\\&|HIS\r\nPID|1\r\n\r\nOBX|1\nOBX|2\r", func(index int, name, raw string) error {
+		visited = append(visited, fmt.Sprintf("%d %s %s", index, name, raw))
+		return nil
+	})
+	expectDeepValue(t, []string{"0 MSH MSH|^~\\&|HIS", "1 PID PID|1", "2 OBX OBX|1", "3 OBX OBX|2"}, visited, err)
+
+	// an error from fn stops the walk
+	stop := errors.New("stop")
+	visited = nil
+	err = ForEachSegment(message, func(index int, name, raw string) error {
+		if name == "OBX" {
+			return stop
+		}
+		visited = append(visited, name)
+		return nil
+	})
+	expectValue(t, stop, err)
+	expectDeepValue(t, []string{"MSH", "PID", "PV1"}, visited)
+
+	err = ForEachSegment("PID|1", func(int, string, string) error { return nil })
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
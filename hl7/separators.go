@@ -0,0 +1,159 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Encoding holds the encoding characters a message declares in MSH-1 and
+// MSH-2, the field separator followed by the component, repetition, escape and
+// subcomponent characters, and optionally the truncation character.
+type Encoding struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	Subcomponent byte
+	// Truncation is the character HL7 2.7 and later use to mark a value that
+	// was truncated, usually #. It is 0 when MSH-2 does not declare one.
+	Truncation byte
+}
+
+// Separators is the name Encoding had before escaping was supported.
+type Separators = Encoding
+
+// DefaultSeparators are the separators recommended by the HL7 standard, |^~\&
+var DefaultSeparators = Encoding{
+	Field:        '|',
+	Component:    '^',
+	Repetition:   '~',
+	Escape:       '\\',
+	Subcomponent: '&',
+}
+
+// ParseEncoding returns the encoding characters message declares in MSH-1
+// and MSH-2, validating them with ValidateMSH.
+func ParseEncoding(message string) (Encoding, error) {
+	return ValidateMSH(message)
+}
+
+// ValidateMSH checks the start of the MSH segment and returns the encoding
+// characters it declares: the message must begin with MSH followed by the
+// field separator and four or five other encoding characters, all different,
+// none of them a segment terminator, and then the field separator again.
+// Every function that reads a message validates it this way. An error
+// matches ErrInvalidMessage.
+func ValidateMSH(message string) (Encoding, error) {
+	/**
+	* First we need to check that the message is mostly valid and extract the separators
+	* - It must begin with MSH
+	* - It must have a field separator at the 4th character
+	* - It must have separators between the first field separator and the 2nd
+	*   field separator in the MSH segment. Like MSH|...| not MSH||
+	* - The separators must not be reused. Like MSH|^~\&| not MSH|1111|
+	* - There can be a max of 5 separators, the optional 5th one is the
+	*   truncation character of HL7 2.7 and later.
+	* - By default, the separators are ^~\&# but they can be interchanged
+	*   dynamically in the MSH segment.
+	 */
+	// validate message begins with MSH
+	if len(message) < 3 || message[:3] != "MSH" {
+		return Encoding{}, fmt.Errorf("%w: must begin with MSH", ErrInvalidMessage)
+	}
+	// get the next 6 characters after MSH which should be the separators
+	// if there are not 6 characters after MSH, it's an error because the separators must be defined
+	if len(message) < 10 {
+		return Encoding{}, fmt.Errorf("%w: message too short to contain separators and meaningful data", ErrInvalidMessage)
+	}
+	separators := message[3:10]
+	fieldSeparator := separators[0]
+	componentSeparator := separators[1]
+	if componentSeparator == fieldSeparator {
+		return Encoding{}, &kindError{ErrInvalidMessage, ErrMissingComponentSeparator}
+	}
+	repetitionSeparator := separators[2]
+	if repetitionSeparator == fieldSeparator {
+		return Encoding{}, &kindError{ErrInvalidMessage, ErrMissingRepetitionSeparator}
+	}
+	escapeCharacter := separators[3]
+	// if escapeCharacter is the same as the fieldSeparator then it is missing
+	if escapeCharacter == fieldSeparator {
+		return Encoding{}, &kindError{ErrInvalidMessage, ErrMissingEscapeCharacter}
+	}
+	subcomponentSeparator := separators[4]
+	if subcomponentSeparator == fieldSeparator {
+		return Encoding{}, &kindError{ErrInvalidMessage, ErrMissingSubcomponentSeparator}
+	}
+	// there could be a 5th separator, the truncation character, but the
+	// separators must end with the field separator again.
+	var truncationCharacter byte
+	if separators[5] != fieldSeparator {
+		if separators[6] != fieldSeparator {
+			return Encoding{}, &kindError{ErrInvalidMessage, errors.New("unexpected extra separators")}
+		}
+		truncationCharacter = separators[5]
+	}
+
+	// check that all separators are unique, naming the two that are not. One
+	// that is the field separator was caught above as missing.
+	chars := []encodingChar{
+		{"field", "separator", fieldSeparator},
+		{"component", "separator", componentSeparator},
+		{"repetition", "separator", repetitionSeparator},
+		{"escape", "character", escapeCharacter},
+		{"subcomponent", "separator", subcomponentSeparator},
+	}
+	if truncationCharacter != 0 {
+		chars = append(chars, encodingChar{"truncation", "character", truncationCharacter})
+	}
+	for _, c := range chars {
+		if c.char == '\r' || c.char == '\n' {
+			return Encoding{}, &kindError{ErrInvalidMessage, errors.New("encoding characters can't be segment terminators")}
+		}
+	}
+	for i, a := range chars {
+		for _, b := range chars[i+1:] {
+			if a.char == b.char {
+				return Encoding{}, &kindError{ErrInvalidMessage, duplicateEncodingError(a, b)}
+			}
+		}
+	}
+
+	return Encoding{
+		Field:        fieldSeparator,
+		Component:    componentSeparator,
+		Repetition:   repetitionSeparator,
+		Escape:       escapeCharacter,
+		Subcomponent: subcomponentSeparator,
+		Truncation:   truncationCharacter,
+	}, nil
+}
+
+// encodingChar is one of the encoding characters of MSH-1 and MSH-2 with its
+// name, for error messages.
+type encodingChar struct {
+	name string
+	kind string
+	char byte
+}
+
+// duplicateEncodingError says which two encoding characters are the same, like
+// "component and subcomponent separators are identical: both '^'".
+func duplicateEncodingError(a, b encodingChar) error {
+	names := a.name + " " + a.kind + " and " + b.name + " " + b.kind
+	if a.kind == b.kind {
+		names = a.name + " and " + b.name + " " + a.kind + "s"
+	}
+	return fmt.Errorf("%s are identical: both %q", names, a.char)
+}
+
+// String renders the encoding characters the way MSH-2 declares them, e.g.
+// ^~\& or ^~\&# with a truncation character. The field separator is MSH-1
+// and not part of it.
+func (e Encoding) String() string {
+	chars := []byte{e.Component, e.Repetition, e.Escape, e.Subcomponent}
+	if e.Truncation != 0 {
+		chars = append(chars, e.Truncation)
+	}
+	return string(chars)
+}
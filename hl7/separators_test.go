@@ -0,0 +1,134 @@
+package hl7
+
+import (
+	"errors"
+	"testing"
+)
+
+// truncatedMessage declares the HL7 2.7 truncation character # in MSH-2 and
+// truncates PID-5.1 and OBX-5 with it.
+var truncatedMessage = "MSH|^~\\&#|HIS|RIH|EKG|EKG|20240101120000||ADT^A01|MSG00003|P|2.7\rPID|||123^^^^MRN||EVERYWOMANWITHAVERYLO#^EVE\rOBX|1|TX|NOTE||Patient reports#"
+
+func TestParseEncoding(t *testing.T) {
+	enc, err := ParseEncoding(message)
+	expectValue(t, DefaultSeparators, enc, err)
+	expectValue(t, byte(0), enc.Truncation)
+
+	enc, err = ParseEncoding(truncatedMessage)
+	expected := DefaultSeparators
+	expected.Truncation = '#'
+	expectValue(t, expected, enc, err)
+
+	enc, err = ParseEncoding("MSH#!@$%*#HIS")
+	expectValue(t, Encoding{Field: '#', Component: '!', Repetition: '@', Escape: '$', Subcomponent: '%', Truncation: '*'}, enc, err)
+
+	_, err = ParseEncoding("MSH|^~\\&##|HIS")
+	expectError(t, err, "unexpected extra separators")
+
+	_, err = ParseEncoding("MSH|^~\\&^|HIS")
+	expectError(t, err, "component separator and truncation character are identical: both '^'")
+}
+
+func TestValidateMSH(t *testing.T) {
+	enc, err := ValidateMSH(message)
+	expectValue(t, DefaultSeparators, enc, err)
+
+	_, err = ValidateMSH("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+	_, err = ValidateMSH("MSH|^~\\&")
+	expectError(t, err, "invalid HL7 message: message too short to contain separators and meaningful data")
+	_, err = ValidateMSH("MSH|^~|&|HIS")
+	expectError(t, err, "missing escape character")
+	expectValue(t, true, errors.Is(err, ErrMissingEscapeCharacter))
+	// the two characters that collide are named
+	_, err = ValidateMSH("MSH|^~\\^|HIS")
+	expectError(t, err, "component and subcomponent separators are identical: both '^'")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+	_, err = ValidateMSH("MSH|^~~&|HIS")
+	expectError(t, err, "repetition separator and escape character are identical: both '~'")
+	_, err = ValidateMSH("MSH|^~\\&\\|HIS")
+	expectError(t, err, "escape and truncation characters are identical: both '\\\\'")
+	_, err = ValidateMSH("MSH|^~\\&&|HIS")
+	expectError(t, err, "subcomponent separator and truncation character are identical: both '&'")
+	// one that is the field separator is still missing
+	_, err = ValidateMSH("MSH|^|\\&|HIS")
+	expectError(t, err, "missing repetition separator")
+
+	// a segment terminator in MSH-2 would split the header
+	_, err = ValidateMSH("MSH|^~\\\r|HIS")
+	expectError(t, err, "encoding characters can't be segment terminators")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+
+	// GenerateACK and SetHL7 validate the header the same way
+	_, err = GenerateACK("MSH|^~\\\r|HIS", "AA")
+	expectError(t, err, "encoding characters can't be segment terminators")
+	_, err = SetHL7("MSH|^~\\\r|HIS", MustParsePath("MSH-3"), "X")
+	expectError(t, err, "encoding characters can't be segment terminators")
+}
+
+func TestTruncation(t *testing.T) {
+	// the truncation character is data unless asked for
+	path, err1 := ParsePath("PID-5.1")
+	resp, err2 := AbstractHL7(truncatedMessage, path)
+	expectValue(t, "EVERYWOMANWITHAVERYLO#", resp, err1, err2)
+
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "EVERYWOMANWITHAVERYLO", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX-5")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "Patient reports", resp, err1, err2)
+
+	// a segment or field only loses the rest of the piece that was truncated
+	path, err1 = ParsePath("PID")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "PID|||123^^^^MRN||EVERYWOMANWITHAVERYLO^EVE", resp, err1, err2)
+	resp, err2 = AbstractHL7Opts("MSH|^~\\&#|HIS|||||||||2.7\rPID|1|A#B~C&D#E^F|G#|H", path, WithTruncation())
+	expectValue(t, "PID|1|A~C&D^F|G|H", resp, err2)
+	path, err1 = ParsePath("PID-5")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "EVERYWOMANWITHAVERYLO^EVE", resp, err1, err2)
+	path, err1 = ParsePath("OBX")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "OBX|1|TX|NOTE||Patient reports", resp, err1, err2)
+
+	// MSH-2 is never cut
+	path, err1 = ParsePath("MSH")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "MSH|^~\\&#|HIS|RIH|EKG|EKG|20240101120000||ADT^A01|MSG00003|P|2.7", resp, err1, err2)
+	path, err1 = ParsePath("MSH-2")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "^~\\&#", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-3.5")
+	resp, err2 = AbstractHL7Opts(truncatedMessage, path, WithTruncation())
+	expectValue(t, "MRN", resp, err1, err2)
+
+	// without a truncation character nothing is cut
+	resp, err2 = AbstractHL7Opts("MSH|^~\\&|HIS\rPID|1|A#B", HL7Path{Segment: "PID", SegmentIndex: 1, Field: 2, RepetitionIndex: 1}, WithTruncation())
+	expectValue(t, "A#B", resp, err2)
+
+	// a parsed message keeps it
+	m, err := Parse(truncatedMessage)
+	expectValue(t, byte('#'), m.Encoding.Truncation, err)
+	expectValue(t, truncatedMessage, m.String())
+
+	enc := m.Encoding
+	expectValue(t, `50\P\ off`, Escape("50# off", enc))
+	expectValue(t, "50# off", Unescape(`50\P\ off`, enc))
+	expectValue(t, `50\P\ off`, Unescape(`50\P\ off`, DefaultSeparators))
+}
+
+func TestEncodingString(t *testing.T) {
+	expectValue(t, "^~\\&", DefaultSeparators.String())
+
+	enc, err := ParseEncoding(truncatedMessage)
+	expectValue(t, "^~\\&#", enc.String(), err)
+
+	enc, err = ParseEncoding("MSH#!@$%#HIS")
+	expectValue(t, "!@$%", enc.String(), err)
+
+	_, err = ParseEncoding("PID|1")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+}
@@ -0,0 +1,259 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SetHL7 returns a copy of message with the value at path replaced by value.
+// Fields, repetitions, components and subcomponents that don't exist yet are
+// created, padding with empty ones as needed, and every segment keeps the
+// terminator it had. A path without a field replaces the whole segment, the
+// value must then start with the segment name and the field separator, and
+// for MSH keep the encoding characters.
+//
+// The value is escaped so it can't spill out of the piece path references,
+// separators below that level are kept: setting PID-5[1] to "DOE^JANE" sets
+// two components while setting PID-5.1 to "DOE^JANE" stores DOE\S\JANE. A
+// whole segment is written as is. WithRawValue writes any value as is, see
+// SetOptions. MSH-1 and MSH-2 hold the encoding characters and can't be set.
+//
+// A segment the message does not have is an error matching
+// ErrSegmentNotFound, unless WithCreateMissingSegments adds it.
+func SetHL7(message string, path HL7Path, value string, opts ...SetOption) (string, error) {
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		return "", errors.New("MSH-1 and MSH-2 hold the encoding characters and can't be set")
+	}
+	o := buildSetOptions(opts)
+	if o.CreateMissingSegments {
+		var err error
+		if message, err = addMissingSegments(message, path); err != nil {
+			return "", err
+		}
+	}
+	return editSegment(message, path, func(segment string, sep Encoding) (string, error) {
+		if o.RawValue {
+			return setRawInSegment(segment, path, value, sep)
+		}
+		return setInSegment(segment, path, value, sep)
+	})
+}
+
+// SetHL7Repetitions returns a copy of message with every repetition of the
+// field at path replaced by values, one repetition each, like writing several
+// patient identifiers to PID-3 at once. The path references the field, PID-3
+// or PID-3[*]. Values are escaped like SetHL7 escapes a repetition, so
+// components and subcomponents are kept. No values empty the field.
+func SetHL7Repetitions(message string, path HL7Path, values []string) (string, error) {
+	if path.Segment == "MSH" && (path.Field == 1 || path.Field == 2) {
+		return "", errors.New("MSH-1 and MSH-2 hold the encoding characters and can't be set")
+	}
+	if path.RepetitionIndex == WildcardRepetition {
+		path.RepetitionIndex = 1
+	}
+	if path.Field == 0 || path.RepetitionIndex != 1 || path.Component != 0 || path.Subcomponent != 0 {
+		return "", errors.New("path must reference a whole field, like PID-3")
+	}
+	return editSegment(message, path, func(segment string, sep Encoding) (string, error) {
+		repetitions := make([]string, len(values))
+		for i, value := range values {
+			repetitions[i] = escape(value, sep, sep.Component, sep.Subcomponent)
+		}
+		return replaceField(segment, path, strings.Join(repetitions, string(sep.Repetition)), sep), nil
+	})
+}
+
+// addMissingSegments adds empty segments named path.Segment after the last
+// one in the message, or at its end, until it has the one path references,
+// see SetOptions.CreateMissingSegments.
+func addMissingSegments(message string, path HL7Path) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", err
+	}
+	if path.Segment == "" || path.SegmentIndex < 1 || path.Where != (SegmentPredicate{}) {
+		return message, nil
+	}
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return "", err
+	}
+	// positions are 1-based and blank lines don't count, like InsertSegment
+	count, last, position := 0, 0, 0
+	for _, segment := range splitByAnyOf(message, []string{"\r\n", "\r", "\n"}) {
+		if segment == "" {
+			continue
+		}
+		position++
+		if isSegment(segment, path.Segment, sep.Field) {
+			count, last = count+1, position
+		}
+	}
+	if last == 0 {
+		last = position
+	}
+	for ; count < path.SegmentIndex; count++ {
+		last++
+		if message, err = InsertSegment(message, last, path.Segment+string(sep.Field)); err != nil {
+			return "", err
+		}
+	}
+	return message, nil
+}
+
+// replaceField replaces field path.Field of segment, every repetition of it,
+// with value, which must already be escaped.
+func replaceField(segment string, path HL7Path, value string, sep Encoding) string {
+	fields := strings.Split(segment, string(sep.Field))
+	i := path.Field
+	if path.Segment == "MSH" {
+		i--
+	}
+	fields = padParts(fields, i+1)
+	fields[i] = value
+	return strings.Join(fields, string(sep.Field))
+}
+
+// editSegment replaces the segment path references with the result of edit,
+// leaving the rest of the message untouched.
+func editSegment(message string, path HL7Path, edit func(segment string, sep Encoding) (string, error)) (string, error) {
+	if err := path.Validate(); err != nil {
+		return "", err
+	}
+	if path.hasWildcard() {
+		return "", errors.New("wildcard paths can't be edited")
+	}
+	if path == (HL7Path{}) {
+		return "", errors.New("path must reference a segment")
+	}
+	sep, err := ValidateMSH(message)
+	if err != nil {
+		return "", err
+	}
+
+	segments, terminators := splitSegmentsKeepingTerminators(message)
+	i := findSegment(segments, path, sep)
+	if i == -1 {
+		return "", &kindError{ErrSegmentNotFound, fmt.Errorf("segment %s%s[%d] not found", path.Segment, path.Where, path.SegmentIndex)}
+	}
+	if segments[i], err = edit(segments[i], sep); err != nil {
+		return "", err
+	}
+	return joinSegments(segments, terminators), nil
+}
+
+// findSegment returns the index of the segment path references, the
+// SegmentIndex-th (1-based, or counted from the end when negative) one it
+// selects, or -1 if there is no such segment.
+func findSegment(segments []string, path HL7Path, sep Encoding) int {
+	n := path.SegmentIndex
+	if n < 0 {
+		n = resolveIndex(n, countSelectedSegments(segments, path, sep))
+	}
+	count := 0
+	for i, segment := range segments {
+		if path.selectsSegment(segment, sep) {
+			count++
+			if count == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func setInSegment(segment string, path HL7Path, value string, sep Encoding) (string, error) {
+	switch {
+	case path.Field == 0:
+		// a whole segment is written as is
+	case path.Component == 0:
+		value = escape(value, sep, sep.Component, sep.Subcomponent)
+	case path.Subcomponent == 0:
+		value = escape(value, sep, sep.Subcomponent)
+	default:
+		value = escape(value, sep)
+	}
+	return setRawInSegment(segment, path, value, sep)
+}
+
+// setRawInSegment is setInSegment for a value that is already escaped.
+func setRawInSegment(segment string, path HL7Path, value string, sep Encoding) (string, error) {
+	if path.Field == 0 {
+		if err := checkWholeSegment(segment, path, value, sep); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+	fields := strings.Split(segment, string(sep.Field))
+	// MSH-1 is the separator after the segment name, so every MSH field is
+	// one piece earlier than its number
+	i := path.Field
+	if path.Segment == "MSH" {
+		i--
+	}
+	fields = padParts(fields, i+1)
+	field, err := setInField(fields[i], path, value, sep)
+	if err != nil {
+		return "", err
+	}
+	fields[i] = field
+	return strings.Join(fields, string(sep.Field)), nil
+}
+
+// checkWholeSegment validates value as the replacement of the whole segment
+// path references: it must be a single line starting with the segment name
+// and the field separator, and a new MSH must keep the encoding characters.
+func checkWholeSegment(segment string, path HL7Path, value string, sep Encoding) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return errors.New("segment must be a single line")
+	}
+	name := path.Segment + string(sep.Field)
+	if !strings.HasPrefix(value, name) {
+		return fmt.Errorf("segment %q must start with %q", value, name)
+	}
+	if path.Segment == "MSH" {
+		// MSH|^~\& up to the field separator after MSH-2
+		encoding, _, _ := strings.Cut(segment[len(name):], string(sep.Field))
+		header := name + encoding
+		if value != header && !strings.HasPrefix(value, header+string(sep.Field)) {
+			return errors.New("MSH-1 and MSH-2 hold the encoding characters and can't be set")
+		}
+	}
+	return nil
+}
+
+func setInField(field string, path HL7Path, value string, sep Encoding) (string, error) {
+	repetitions := strings.Split(field, string(sep.Repetition))
+	// a repetition counted from the end has to exist already, there is no
+	// way to pad in front of the first one
+	r := resolveIndex(path.RepetitionIndex, len(repetitions))
+	if r < 1 {
+		return "", fmt.Errorf("repetition %d of %s-%d does not exist", path.RepetitionIndex, path.Segment, path.Field)
+	}
+	repetitions = padParts(repetitions, r)
+	i := r - 1
+	if path.Component == 0 {
+		repetitions[i] = value
+	} else {
+		// separators in escape sequences are data, like AbstractHL7 reads them
+		components := padParts(splitEscaped(repetitions[i], sep.Component, sep.Escape), path.Component)
+		c := path.Component - 1
+		if path.Subcomponent == 0 {
+			components[c] = value
+		} else {
+			subcomponents := padParts(splitEscaped(components[c], sep.Subcomponent, sep.Escape), path.Subcomponent)
+			subcomponents[path.Subcomponent-1] = value
+			components[c] = strings.Join(subcomponents, string(sep.Subcomponent))
+		}
+		repetitions[i] = strings.Join(components, string(sep.Component))
+	}
+	return strings.Join(repetitions, string(sep.Repetition)), nil
+}
+
+// padParts appends empty parts until there are at least n.
+func padParts(parts []string, n int) []string {
+	for len(parts) < n {
+		parts = append(parts, "")
+	}
+	return parts
+}
@@ -0,0 +1,309 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetHL7(t *testing.T) {
+	path, err1 := ParsePath("PID-8")
+	resp, err2 := SetHL7(message, path, "M")
+	expectValue(t, strings.Replace(message, "|19610615|F|", "|19610615|M|", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-3")
+	resp, err2 = SetHL7(message, path, "APP")
+	expectValue(t, strings.Replace(message, "|HIS|", "|APP|", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[2]-5")
+	resp, err2 = SetHL7(message, path, "80")
+	expectValue(t, strings.Replace(message, "||79|", "||80|", 1), resp, err1, err2)
+
+	// a whole segment
+	path, err1 = ParsePath("ZZZ[2]")
+	resp, err2 = SetHL7(message, path, "ZZZ|1")
+	expectValue(t, strings.Replace(message, "ZZZ||foo|bar|baz", "ZZZ|1", 1), resp, err1, err2)
+
+	// values are escaped below the level the path references
+	path, err1 = ParsePath("PID-11")
+	resp, err2 = SetHL7(message, path, "1 MAIN ST^^CITY|STATE")
+	expectValue(t, strings.Replace(message, "|2222 HOMES TREET^^GREENSBORO^NC^27401|", `|1 MAIN ST^^CITY\F\STATE|`, 1), resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5.1")
+	resp, err2 = SetHL7(message, path, "DOE^JANE~X&Y")
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^", `|DOE\S\JANE\R\X&Y^`, 1), resp, err1, err2)
+
+	path, err1 = ParsePath("PID-5.1.1")
+	resp, err2 = SetHL7(message, path, `X&Y\`)
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^", `|X\T\Y\E\^`, 1), resp, err1, err2)
+
+	// a field replaces only the repetition it references
+	path, err1 = ParsePath("PID-3[2]")
+	resp, err2 = SetHL7(message, path, "456^^^^MRN")
+	expectValue(t, strings.Replace(message, "~123^^^^MRN", "~456^^^^MRN", 1), resp, err1, err2)
+}
+
+func TestSetHL7RawValue(t *testing.T) {
+	// by default separators in the value are escaped and it stays one value
+	path := MustParsePath("PID-5[1]")
+	resp, err := SetHL7(message, path, "DOE~SMITH^JANE")
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^EVE^E^^^^L~", `|DOE\R\SMITH^JANE~`, 1), resp, err)
+	value, err := AbstractHL7(resp, MustParsePath("PID-5[2].1"))
+	expectValue(t, "QUE", value, err)
+
+	// raw, they make repetitions, components and subcomponents
+	resp, err = SetHL7(message, path, "DOE~SMITH^JANE", WithRawValue())
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^EVE^E^^^^L~", "|DOE~SMITH^JANE~", 1), resp, err)
+	value, err = AbstractHL7(resp, MustParsePath("PID-5[2].1"))
+	expectValue(t, "SMITH", value, err)
+
+	resp, err = SetHL7(message, MustParsePath("PID-5.1"), "DOE&JR^JANE", WithRawValue())
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^", "|DOE&JR^JANE^", 1), resp, err)
+	value, err = AbstractHL7(resp, MustParsePath("PID-5.1.2"))
+	expectValue(t, "JR", value, err)
+
+	// escape sequences are written as they are instead of escaping the escape
+	resp, err = SetHL7(message, MustParsePath("PID-5.1"), `A\T\B`, WithRawValue())
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^", `|A\T\B^`, 1), resp, err)
+	resp, err = SetHL7(message, MustParsePath("PID-5.1"), `A\T\B`)
+	expectValue(t, strings.Replace(message, "|EVERYWOMAN^", `|A\E\T\E\B^`, 1), resp, err)
+
+	// the encoding characters still can't be set
+	_, err = SetHL7(message, MustParsePath("MSH-2"), "^~\\&", WithRawValue())
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+}
+
+func TestSetHL7CreateMissingSegments(t *testing.T) {
+	path := MustParsePath("OBX[5]-5")
+
+	// by default a segment past the last one is an error
+	_, err := SetHL7(message, path, "80")
+	expectError(t, err, "segment OBX[5] not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+
+	// the missing ones are added after the last OBX, empty but for the value
+	resp, err := SetHL7(message, path, "80", WithCreateMissingSegments())
+	expectValue(t, strings.Replace(message, "|50-100|N|||F\r", "|50-100|N|||F\rOBX|\rOBX|\rOBX|||||80\r", 1), resp, err)
+	value, err := AbstractHL7(resp, path)
+	expectValue(t, "80", value, err)
+	count, err := CountSegments(resp, "OBX")
+	expectValue(t, 5, count, err)
+
+	// a segment that is there already is set as usual
+	resp, err = SetHL7(message, MustParsePath("OBX[2]-5"), "80", WithCreateMissingSegments())
+	expectValue(t, strings.Replace(message, "||79|", "||80|", 1), resp, err)
+
+	// a segment the message doesn't have goes at the end, terminated like the
+	// others
+	resp, err = SetHL7(message+"\r", MustParsePath("NK1[2]-2.1"), "DOE", WithCreateMissingSegments())
+	expectValue(t, message+"\rNK1|\rNK1||DOE\r", resp, err)
+	resp, err = SetHL7(message, MustParsePath("NK1"), "NK1|1|DOE", WithCreateMissingSegments())
+	expectValue(t, message+"\rNK1|1|DOE", resp, err)
+
+	// there is nothing to create from the end or from a predicate
+	_, err = SetHL7(message, MustParsePath("OBX[-3]-5"), "80", WithCreateMissingSegments())
+	expectError(t, err, "segment OBX[-3] not found")
+	_, err = SetHL7(message, MustParsePath("OBX{3.2=Body Temp}-5"), "37", WithCreateMissingSegments())
+	expectError(t, err, "segment OBX{3.2=Body Temp}[1] not found")
+
+	_, err = SetHL7("PID|1", path, "80", WithCreateMissingSegments())
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+
+	// a created segment is only replaced by one with its name
+	_, err = SetHL7(message, MustParsePath("NK1"), "anything", WithCreateMissingSegments())
+	expectError(t, err, `segment "anything" must start with "NK1|"`)
+}
+
+func TestSetHL7EscapedSeparators(t *testing.T) {
+	// the ^ inside \Zx^y\ is data, ZZZ-2.2 is F for reading and writing
+	msg := "MSH|^~\\&|HIS\rZZZ|1|C\\Zx^y\\D^F"
+	path := MustParsePath("ZZZ-2.2")
+	value, err := AbstractHL7(msg, path)
+	expectValue(t, "F", value, err)
+
+	resp, err := SetHL7(msg, path, "NEW")
+	expectValue(t, "MSH|^~\\&|HIS\rZZZ|1|C\\Zx^y\\D^NEW", resp, err)
+	value, err = AbstractHL7(resp, path)
+	expectValue(t, "NEW", value, err)
+	value, err = AbstractHL7(resp, MustParsePath("ZZZ-2.1"))
+	expectValue(t, "C\\Zx^y\\D", value, err)
+
+	// the same goes for subcomponents
+	msg = "MSH|^~\\&|HIS\rZZZ|1|A\\Zx&y\\B&C"
+	path = MustParsePath("ZZZ-2.1.2")
+	resp, err = SetHL7(msg, path, "NEW")
+	expectValue(t, "MSH|^~\\&|HIS\rZZZ|1|A\\Zx&y\\B&NEW", resp, err)
+	value, err = AbstractHL7(resp, path)
+	expectValue(t, "NEW", value, err)
+}
+
+func TestSetHL7Padding(t *testing.T) {
+	msg := "MSH|^~\\&|HIS\rPID|1|A^B\rPV1|1"
+
+	path, err1 := ParsePath("PV1-4")
+	resp, err2 := SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A^B\rPV1|1|||X", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-2.4")
+	resp, err2 = SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A^B^^X\rPV1|1", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-2.2.3")
+	resp, err2 = SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A^B&&X\rPV1|1", resp, err1, err2)
+
+	path, err1 = ParsePath("PID-3[3].2")
+	resp, err2 = SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|A^B|~~^X\rPV1|1", resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-5")
+	resp, err2 = SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS||X\rPID|1|A^B\rPV1|1", resp, err1, err2)
+}
+
+func TestSetHL7TrailingEmptyFields(t *testing.T) {
+	// trailing empty fields are positions too and are never trimmed
+	msg := "MSH|^~\\&|HIS||\rPID|1||X|\rPV1|1|^^|~"
+
+	path, err1 := ParsePath("PID-2")
+	resp, err2 := SetHL7(msg, path, "Y")
+	expectValue(t, "MSH|^~\\&|HIS||\rPID|1|Y|X|\rPV1|1|^^|~", resp, err1, err2)
+
+	path, err1 = ParsePath("PV1-2.1")
+	resp, err2 = SetHL7(msg, path, "A")
+	expectValue(t, "MSH|^~\\&|HIS||\rPID|1||X|\rPV1|1|A^^|~", resp, err1, err2)
+
+	// a high field index creates the empty fields before it, which stay
+	// where they are through another edit and a parse
+	path, err1 = ParsePath("PID-20")
+	resp, err2 = SetHL7(msg, path, "Z")
+	expectValue(t, "MSH|^~\\&|HIS||\rPID|1||X|||||||||||||||||Z\rPV1|1|^^|~", resp, err1, err2)
+	path, err1 = ParsePath("PID-10")
+	resp, err2 = SetHL7(resp, path, "W")
+	expectValue(t, "MSH|^~\\&|HIS||\rPID|1||X|||||||W||||||||||Z\rPV1|1|^^|~", resp, err1, err2)
+	parsed, err1 := Parse(resp)
+	expectValue(t, resp, parsed.String(), err1)
+	expectValue(t, 20, len(parsed.Segments[1].Fields))
+	value, err1 := parsed.Get(MustParsePath("PID-20"))
+	expectValue(t, "Z", value, err1)
+
+	// an empty value keeps the field it empties
+	path, err1 = ParsePath("PID-20")
+	resp, err2 = SetHL7(resp, path, "")
+	expectValue(t, "MSH|^~\\&|HIS||\rPID|1||X|||||||W||||||||||\rPV1|1|^^|~", resp, err1, err2)
+	parsed, err1 = Parse(resp)
+	expectValue(t, resp, parsed.String(), err1)
+}
+
+func TestSetHL7NegativeIndex(t *testing.T) {
+	path, err1 := ParsePath("PID-3[-1].1")
+	resp, err2 := SetHL7(message, path, "456")
+	expectValue(t, strings.Replace(message, "~123^", "~456^", 1), resp, err1, err2)
+
+	path, err1 = ParsePath("OBX[-1]-5")
+	resp, err2 = SetHL7(message, path, "80")
+	expectValue(t, strings.Replace(message, "||79|", "||80|", 1), resp, err1, err2)
+
+	path, _ = ParsePath("PID-3[-3]")
+	_, err := SetHL7(message, path, "1")
+	expectError(t, err, "repetition -3 of PID-3 does not exist")
+
+	path, _ = ParsePath("OBX[-3]-5")
+	_, err = SetHL7(message, path, "1")
+	expectError(t, err, "segment OBX[-3] not found")
+}
+
+func TestSetHL7Predicate(t *testing.T) {
+	path, err1 := ParsePath("OBX{3.2=Body Weight}-5")
+	resp, err2 := SetHL7(message, path, "80")
+	expectValue(t, strings.Replace(message, "||79|", "||80|", 1), resp, err1, err2)
+
+	path, _ = ParsePath("OBX{3.2=Body Temp}-5")
+	_, err := SetHL7(message, path, "37")
+	expectError(t, err, "segment OBX{3.2=Body Temp}[1] not found")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+}
+
+func TestSetHL7SegmentSeparators(t *testing.T) {
+	msg := "MSH|^~\\&|HIS\r\nPID|1\nPV1|1\rOBX|1\r\n"
+
+	path, err1 := ParsePath("PID-2")
+	resp, err2 := SetHL7(msg, path, "X")
+	expectValue(t, "MSH|^~\\&|HIS\r\nPID|1|X\nPV1|1\rOBX|1\r\n", resp, err1, err2)
+
+	path, err1 = ParsePath("OBX-1")
+	resp, err2 = SetHL7(msg, path, "2")
+	expectValue(t, "MSH|^~\\&|HIS\r\nPID|1\nPV1|1\rOBX|2\r\n", resp, err1, err2)
+}
+
+func TestSetHL7Errors(t *testing.T) {
+	path, _ := ParsePath("MSH-1")
+	_, err := SetHL7(message, path, "#")
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+
+	path, _ = ParsePath("MSH-2")
+	_, err = SetHL7(message, path, "^~\\&")
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+
+	path, _ = ParsePath("OBX[3]-5")
+	_, err = SetHL7(message, path, "1")
+	expectError(t, err, "segment OBX[3] not found")
+
+	path, _ = ParsePath("PID-3[*]")
+	_, err = SetHL7(message, path, "1")
+	expectError(t, err, "wildcard paths can't be edited")
+
+	_, err = SetHL7(message, HL7Path{}, "1")
+	expectError(t, err, "path must reference a segment")
+
+	// a whole segment keeps its name and the encoding characters of MSH
+	path, _ = ParsePath("MSH")
+	_, err = SetHL7(message, path, "MSH*x")
+	expectError(t, err, `segment "MSH*x" must start with "MSH|"`)
+	_, err = SetHL7(message, path, "MSH|#~\\&|HIS", WithRawValue())
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+	_, err = SetHL7(message, path, "MSH|^~\\&x")
+	expectError(t, err, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+	resp, err := SetHL7(message, path, "MSH|^~\\&|APP")
+	expectValue(t, strings.Replace(message, message[:strings.Index(message, "\r")], "MSH|^~\\&|APP", 1), resp, err)
+	path, _ = ParsePath("PID")
+	_, err = SetHL7(message, path, "ZZZ|1")
+	expectError(t, err, `segment "ZZZ|1" must start with "PID|"`)
+	_, err = SetHL7(message, path, "PID|1\rZZZ|1")
+	expectError(t, err, "segment must be a single line")
+
+	path, _ = ParsePath("PID-8")
+	_, err = SetHL7("PID|1", path, "M")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestSetHL7Repetitions(t *testing.T) {
+	msg := "MSH|^~\\&|HIS\rPID|1||555-44-4444^^^^SSN~123^^^^MRN|X\rPV1|1"
+
+	path, err1 := ParsePath("PID-3")
+	resp, err2 := SetHL7Repetitions(msg, path, []string{"1^^^^MRN", "2^^^^SSN", "3&4"})
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1||1^^^^MRN~2^^^^SSN~3&4|X\rPV1|1", resp, err1, err2)
+
+	// values are escaped, the field is created if it doesn't exist
+	path, err1 = ParsePath("PV1-3[*]")
+	resp, err2 = SetHL7Repetitions(msg, path, []string{"A~B", "C|D\\E"})
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1||555-44-4444^^^^SSN~123^^^^MRN|X\rPV1|1||A\\R\\B~C\\F\\D\\E\\E", resp, err1, err2)
+
+	// no values empty the field
+	path, err1 = ParsePath("PID-3")
+	resp, err2 = SetHL7Repetitions(msg, path, nil)
+	expectValue(t, "MSH|^~\\&|HIS\rPID|1|||X\rPV1|1", resp, err1, err2)
+
+	path, err1 = ParsePath("MSH-4")
+	resp, err2 = SetHL7Repetitions(msg, path, []string{"A", "B"})
+	expectValue(t, "MSH|^~\\&|HIS|A~B\rPID|1||555-44-4444^^^^SSN~123^^^^MRN|X\rPV1|1", resp, err1, err2)
+
+	_, err2 = SetHL7Repetitions(msg, MustParsePath("PID-3.1"), []string{"1"})
+	expectError(t, err2, "path must reference a whole field, like PID-3")
+	_, err2 = SetHL7Repetitions(msg, MustParsePath("PID-3[2]"), []string{"1"})
+	expectError(t, err2, "path must reference a whole field, like PID-3")
+	_, err2 = SetHL7Repetitions(msg, MustParsePath("MSH-2"), []string{"^~\\&"})
+	expectError(t, err2, "MSH-1 and MSH-2 hold the encoding characters and can't be set")
+	_, err2 = SetHL7Repetitions(msg, MustParsePath("OBX-5"), []string{"1"})
+	expectError(t, err2, "segment OBX[1] not found")
+}
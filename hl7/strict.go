@@ -0,0 +1,76 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkInMessage returns an *ExtractError at StageValue when the message does
+// not reach path, see Options.Strict. The message must already be validated
+// and path be valid, without wildcards and not the empty path.
+func checkInMessage(message string, path HL7Path) error {
+	sep, _ := ParseEncoding(message)
+	segments := splitByAnyOf(message, []string{"\r\n", "\r", "\n"})
+	notFound := func(level string, index int, reason string) error {
+		segment := HL7Path{Segment: path.Segment, Where: path.Where, SegmentIndex: path.SegmentIndex}.String()
+		kind := ErrValueNotFound
+		if level == LevelSegment {
+			kind = ErrSegmentNotFound
+		}
+		return &ExtractError{
+			Path:    path,
+			Stage:   StageValue,
+			Segment: segment,
+			Level:   level,
+			Reason:  reason,
+			Err:     &kindError{kind, fmt.Errorf("%s: %s %d not found, %s", path, level, index, reason)},
+		}
+	}
+
+	count := countSelectedSegments(segments, path, sep)
+	i := findSegment(segments, path, sep)
+	if i == -1 {
+		return notFound(LevelSegment, path.SegmentIndex, fmt.Sprintf("the message has %s", plural(count, path.Segment+path.Where.String()+" segment")))
+	}
+	if path.Field == 0 {
+		return nil
+	}
+	fields := strings.Split(segments[i], string(sep.Field))
+	if path.Segment == "MSH" {
+		fields = append(fields[:1], append([]string{string(sep.Field)}, fields[1:]...)...)
+	}
+	if path.Field >= len(fields) {
+		return notFound(LevelField, path.Field, fmt.Sprintf("the segment has %s", plural(len(fields)-1, "field")))
+	}
+	repetitions := []string{fields[path.Field]}
+	if !(path.Segment == "MSH" && path.Field <= 2) {
+		repetitions = strings.Split(fields[path.Field], string(sep.Repetition))
+	}
+	r := resolveIndex(path.RepetitionIndex, len(repetitions))
+	if r < 1 || r > len(repetitions) {
+		return notFound(LevelRepetition, path.RepetitionIndex, fmt.Sprintf("the field has %s", plural(len(repetitions), "repetition")))
+	}
+	if path.Component == 0 {
+		return nil
+	}
+	components := splitEscaped(repetitions[r-1], sep.Component, sep.Escape)
+	if path.Component > len(components) {
+		return notFound(LevelComponent, path.Component, fmt.Sprintf("the repetition has %s", plural(len(components), "component")))
+	}
+	if path.Subcomponent == 0 {
+		return nil
+	}
+	subcomponents := splitEscaped(components[path.Component-1], sep.Subcomponent, sep.Escape)
+	if path.Subcomponent > len(subcomponents) {
+		return notFound(LevelSubcomponent, path.Subcomponent, fmt.Sprintf("the component has %s", plural(len(subcomponents), "subcomponent")))
+	}
+	return nil
+}
+
+// plural counts n of noun, like "1 field" or "2 fields".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,56 @@
+package hl7
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAbstractHL7OptsStrict(t *testing.T) {
+	// values that are in the message are returned, empty ones too
+	resp, err := AbstractHL7Opts(message, MustParsePath("OBX[2]-5"), WithStrict())
+	expectValue(t, "79", resp, err)
+	resp, err = AbstractHL7Opts(message, MustParsePath("PID-4"), WithStrict())
+	expectValue(t, "", resp, err)
+	resp, err = AbstractHL7Opts(message, MustParsePath("MSH-2"), WithStrict())
+	expectValue(t, "^~\\&", resp, err)
+	resp, err = AbstractHL7Opts(message, MustParsePath("ZZZ[-1]"), WithStrict())
+	expectValue(t, "ZZZ||foo|bar|baz", resp, err)
+
+	var extractErr *ExtractError
+	path := MustParsePath("OBX[3]-5")
+	_, err = AbstractHL7Opts(message, path, WithStrict())
+	expectError(t, err, "OBX[3]-5: segment 3 not found, the message has 2 OBX segments")
+	expectValue(t, true, errors.Is(err, ErrSegmentNotFound))
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, ExtractError{Path: path, Stage: StageValue, Segment: "OBX[3]", Level: LevelSegment, Reason: "the message has 2 OBX segments", Err: extractErr.Err}, *extractErr)
+
+	_, err = AbstractHL7Opts(message, MustParsePath("OBX{3.2=Body Temp}-5"), WithStrict())
+	expectError(t, err, "OBX{3.2=Body Temp}-5: segment 1 not found, the message has 0 OBX{3.2=Body Temp} segments")
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, "OBX{3.2=Body Temp}", extractErr.Segment)
+
+	_, err = AbstractHL7Opts(message, MustParsePath("NK1-2"), WithStrict())
+	expectError(t, err, "NK1-2: segment 1 not found, the message has 0 NK1 segments")
+
+	_, err = AbstractHL7Opts(message, MustParsePath("PV1-19"), WithStrict())
+	expectError(t, err, "PV1-19: field 19 not found, the segment has 18 fields")
+	expectValue(t, true, errors.Is(err, ErrValueNotFound))
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, "PV1", extractErr.Segment)
+	expectValue(t, LevelField, extractErr.Level)
+
+	_, err = AbstractHL7Opts(message, MustParsePath("PID-5[3]"), WithStrict())
+	expectError(t, err, "PID-5[3]: repetition 3 not found, the field has 2 repetitions")
+	_, err = AbstractHL7Opts(message, MustParsePath("PID-7[-2]"), WithStrict())
+	expectError(t, err, "PID-7[-2]: repetition -2 not found, the field has 1 repetition")
+	_, err = AbstractHL7Opts(message, MustParsePath("PID-5.8"), WithStrict())
+	expectError(t, err, "PID-5.8: component 8 not found, the repetition has 7 components")
+	_, err = AbstractHL7Opts(message, MustParsePath("ZZZ-2[2].2.4"), WithStrict())
+	expectError(t, err, "ZZZ-2[2].2.4: subcomponent 4 not found, the component has 3 subcomponents")
+	expectValue(t, true, errors.As(err, &extractErr))
+	expectValue(t, LevelSubcomponent, extractErr.Level)
+
+	// without it a missing value is empty
+	resp, err = AbstractHL7Opts(message, MustParsePath("PV1-19"))
+	expectValue(t, "", resp, err)
+}
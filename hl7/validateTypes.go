@@ -0,0 +1,128 @@
+package hl7
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TypeViolation is a field value that does not match the data type its
+// schema declares.
+type TypeViolation struct {
+	Path   HL7Path
+	Value  string
+	Reason string
+}
+
+var (
+	numericExp  = regexp.MustCompile(`^[+-]?(?:\d+\.?\d*|\.\d+)$`)
+	sequenceExp = regexp.MustCompile(`^\d+$`)
+	dateExp     = regexp.MustCompile(`^(\d{4})(?:(\d{2})(?:(\d{2}))?)?$`)
+	timeExp     = regexp.MustCompile(`^(\d{2})(?:(\d{2})(?:(\d{2})(?:\.\d{1,4})?)?)?(?:[+-]\d{4})?$`)
+	// a DTM or TS, ParseHL7Time also reads the fraction and offset groups
+	dateTimeExp = regexp.MustCompile(`^(\d{4})(?:(\d{2})(?:(\d{2})(?:(\d{2})(?:(\d{2})(?:(\d{2})(?:\.(\d{1,4}))?)?)?)?)?)?(?:([+-])(\d{2})(\d{2}))?$`)
+)
+
+// ValidateTypes checks the value of every field the schema describes against
+// its declared data type, e.g. NM must be numeric and DT must be a date, and
+// returns every violation found. Only primitive types are checked, composite
+// types such as CX or XPN are not inspected, and empty values are never a
+// violation. An error is only returned for a malformed message.
+func (s *Schema) ValidateTypes(message string) ([]TypeViolation, error) {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return nil, err
+	}
+	var violations []TypeViolation
+	occurrences := map[string]int{}
+	for _, segment := range segmentLines(message) {
+		fields := strings.Split(segment, string(sep.Field))
+		name := fields[0]
+		occurrences[name]++
+		seg, ok := s.Segments[name]
+		if !ok {
+			continue
+		}
+		if name == "MSH" {
+			fields = append(fields[:1], append([]string{string(sep.Field)}, fields[1:]...)...)
+		}
+		for f := 1; f < len(fields) && f <= len(seg.Fields); f++ {
+			// MSH-1 and MSH-2 are the separators, not data
+			if name == "MSH" && f <= 2 {
+				continue
+			}
+			dataType := seg.Fields[f-1].DataType
+			for r, repetition := range strings.Split(fields[f], string(sep.Repetition)) {
+				// TS is a composite with the time in its first component
+				value := repetition
+				if dataType == "TS" {
+					value, _, _ = strings.Cut(repetition, string(sep.Component))
+				}
+				if value == "" || value == `""` {
+					continue
+				}
+				if reason := checkDataType(dataType, value); reason != "" {
+					violations = append(violations, TypeViolation{
+						Path: HL7Path{
+							Segment:         name,
+							SegmentIndex:    occurrences[name],
+							Field:           f,
+							RepetitionIndex: r + 1,
+						},
+						Value:  repetition,
+						Reason: reason,
+					})
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// checkDataType returns why value is not valid for the primitive data type,
+// or an empty string if it is valid or the type is not checked.
+func checkDataType(dataType string, value string) string {
+	switch dataType {
+	case "NM":
+		if !numericExp.MatchString(value) {
+			return "NM must be numeric"
+		}
+	case "SI":
+		if !sequenceExp.MatchString(value) {
+			return "SI must be a non-negative integer"
+		}
+	case "DT":
+		m := dateExp.FindStringSubmatch(value)
+		if m == nil || !validDateTime(m[1], m[2], m[3], "", "", "") {
+			return "DT must be a date formatted YYYY[MM[DD]]"
+		}
+	case "TM":
+		m := timeExp.FindStringSubmatch(value)
+		if m == nil || !validDateTime("2000", "", "", m[1], m[2], m[3]) {
+			return "TM must be a time formatted HH[MM[SS[.S[S[S[S]]]]]][+/-ZZZZ]"
+		}
+	case "DTM", "TS":
+		m := dateTimeExp.FindStringSubmatch(value)
+		if m == nil || !validDateTime(m[1], m[2], m[3], m[4], m[5], m[6]) {
+			return dataType + " must be a date/time formatted YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]"
+		}
+	}
+	return ""
+}
+
+// validDateTime checks that the parts of a date/time are in range, parts that
+// are not given are skipped.
+func validDateTime(year, month, day, hour, minute, second string) bool {
+	layout, value := "2006", year
+	for _, part := range []struct{ layout, value string }{
+		{"01", month}, {"02", day}, {"15", hour}, {"04", minute}, {"05", second},
+	} {
+		if part.value == "" {
+			continue
+		}
+		layout += part.layout
+		value += part.value
+	}
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
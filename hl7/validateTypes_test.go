@@ -0,0 +1,87 @@
+package hl7
+
+import "testing"
+
+var typesSchema = []byte(`{
+	"segments": {
+		"MSH": {
+			"fields": [
+				{"data_type": "ST"}, {"data_type": "ST"}, {"data_type": "HD"}, {"data_type": "HD"},
+				{"data_type": "HD"}, {"data_type": "HD"}, {"data_type": "TS"}
+			]
+		},
+		"PID": {
+			"fields": [
+				{"data_type": "SI"}, {"data_type": "CX"}, {"data_type": "CX", "max": -1},
+				{"data_type": "CX"}, {"data_type": "XPN", "max": -1}, {"data_type": "ST"},
+				{"data_type": "TS"}
+			]
+		},
+		"OBX": {
+			"fields": [
+				{"data_type": "SI"}, {"data_type": "ID"}, {"data_type": "CE"}, {"data_type": "ST"},
+				{"data_type": "NM"}, {"data_type": "CE"}, {"data_type": "ST"}, {"data_type": "IS"},
+				{"data_type": "NM"}, {"data_type": "ID"}, {"data_type": "ID"}, {"data_type": "DT"},
+				{"data_type": "TM"}
+			]
+		}
+	}
+}`)
+
+func TestValidateTypes(t *testing.T) {
+	schema, err := LoadSchema(typesSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := schema.ValidateTypes(message)
+	expectDeepValue(t, []TypeViolation(nil), violations, err)
+
+	bad := "MSH|^~\\&|HIS|RIH|EKG|EKG|20061329090131||ORU^R01|MSG00001|P|2.5\r" +
+		"PID|A|||EVERYWOMAN^EVE||19610615^D\r" +
+		"OBX|1|NM|^Body Height||1.8O|m|||\"\"|||20060230|0901\r" +
+		"OBX|2|NM|^Body Weight||79|kg|||||F|2006|2561"
+	violations, err = schema.ValidateTypes(bad)
+	expectDeepValue(t, []TypeViolation{
+		{
+			Path:   HL7Path{Segment: "MSH", SegmentIndex: 1, Field: 7, RepetitionIndex: 1},
+			Value:  "20061329090131",
+			Reason: "TS must be a date/time formatted YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]",
+		},
+		{
+			Path:   HL7Path{Segment: "PID", SegmentIndex: 1, Field: 1, RepetitionIndex: 1},
+			Value:  "A",
+			Reason: "SI must be a non-negative integer",
+		},
+		{
+			Path:   HL7Path{Segment: "OBX", SegmentIndex: 1, Field: 5, RepetitionIndex: 1},
+			Value:  "1.8O",
+			Reason: "NM must be numeric",
+		},
+		{
+			Path:   HL7Path{Segment: "OBX", SegmentIndex: 1, Field: 12, RepetitionIndex: 1},
+			Value:  "20060230",
+			Reason: "DT must be a date formatted YYYY[MM[DD]]",
+		},
+		{
+			Path:   HL7Path{Segment: "OBX", SegmentIndex: 2, Field: 13, RepetitionIndex: 1},
+			Value:  "2561",
+			Reason: "TM must be a time formatted HH[MM[SS[.S[S[S[S]]]]]][+/-ZZZZ]",
+		},
+	}, violations, err)
+
+	_, err = schema.ValidateTypes("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestCheckDataType(t *testing.T) {
+	expectValue(t, "", checkDataType("NM", "-12.5"))
+	expectValue(t, "", checkDataType("NM", ".5"))
+	expectValue(t, "", checkDataType("DT", "2006"))
+	expectValue(t, "", checkDataType("DT", "200605"))
+	expectValue(t, "", checkDataType("TM", "235959.1234-0500"))
+	expectValue(t, "", checkDataType("DTM", "20060529090131.25+0100"))
+	expectValue(t, "", checkDataType("XPN", "anything^goes"))
+	expectValue(t, "DT must be a date formatted YYYY[MM[DD]]", checkDataType("DT", "2006052"))
+	expectValue(t, "DTM must be a date/time formatted YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ]", checkDataType("DTM", "2006052909013"))
+}
@@ -0,0 +1,97 @@
+package hl7
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is an HL7 v2 version as declared in MSH-12, e.g. 2.5.1.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Versions some rules depend on.
+var (
+	Version23  = Version{Major: 2, Minor: 3}
+	Version24  = Version{Major: 2, Minor: 4}
+	Version25  = Version{Major: 2, Minor: 5}
+	Version251 = Version{Major: 2, Minor: 5, Patch: 1}
+	// Version27 introduced the truncation character, the optional 5th
+	// encoding character.
+	Version27 = Version{Major: 2, Minor: 7}
+)
+
+// ParseVersion parses a version like 2.5 or 2.5.1.
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid HL7 version %q", s)
+	}
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part[0] == '+' {
+			return Version{}, fmt.Errorf("invalid HL7 version %q", s)
+		}
+		numbers[i] = n
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// String returns the version the way MSH-12 declares it, 2.5 or 2.5.1.
+func (v Version) String() string {
+	if v.Patch == 0 {
+		return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1 if v is earlier than other, 1 if it is later and 0 if
+// they are the same version.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is other or a later version.
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// MessageVersion returns the version the message declares in MSH-12, the
+// first component when it is a VID.
+func MessageVersion(message string) (Version, error) {
+	value, err := AbstractHL7(message, HL7Path{
+		Segment:         "MSH",
+		SegmentIndex:    1,
+		Field:           12,
+		RepetitionIndex: 1,
+		Component:       1,
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	if value == "" {
+		return Version{}, errors.New("message has no version (MSH-12)")
+	}
+	return ParseVersion(value)
+}
+
+// allowsTruncation reports whether the truncation character a message
+// declares is one, it is only part of HL7 since 2.7. A message without a
+// readable version gets the benefit of the doubt.
+func allowsTruncation(message string) bool {
+	version, err := MessageVersion(message)
+	return err != nil || version.AtLeast(Version27)
+}
@@ -0,0 +1,73 @@
+package hl7
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("2.5.1")
+	expectValue(t, Version251, v, err)
+	expectValue(t, "2.5.1", v.String())
+
+	v, err = ParseVersion("2.7")
+	expectValue(t, Version27, v, err)
+	expectValue(t, "2.7", v.String())
+
+	for _, s := range []string{"", "2", "2.5.1.1", "2.x", "v2.5", "2.-5", "2.+5"} {
+		_, err = ParseVersion(s)
+		expectError(t, err, `invalid HL7 version "`+s+`"`)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	expectValue(t, 0, Version25.Compare(Version{Major: 2, Minor: 5}))
+	expectValue(t, -1, Version25.Compare(Version251))
+	expectValue(t, 1, Version27.Compare(Version251))
+	expectValue(t, -1, Version23.Compare(Version24))
+	expectValue(t, true, Version27.AtLeast(Version27))
+	expectValue(t, false, Version251.AtLeast(Version27))
+	expectValue(t, true, Version{Major: 2, Minor: 8}.AtLeast(Version27))
+}
+
+func TestMessageVersion(t *testing.T) {
+	v, err := MessageVersion(message)
+	expectValue(t, Version25, v, err)
+
+	v, err = MessageVersion(truncatedMessage)
+	expectValue(t, Version27, v, err)
+
+	// a VID has the version in its first component
+	v, err = MessageVersion("MSH|^~\\&|HIS||||||ADT^A01|1|P|2.5.1^USA")
+	expectValue(t, Version251, v, err)
+
+	_, err = MessageVersion("MSH|^~\\&|HIS||||||ADT^A01|1|P")
+	expectError(t, err, "message has no version (MSH-12)")
+
+	_, err = MessageVersion("MSH|^~\\&|HIS||||||ADT^A01|1|P|two")
+	expectError(t, err, `invalid HL7 version "two"`)
+
+	_, err = MessageVersion("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestTruncationVersion(t *testing.T) {
+	// before 2.7 there is no truncation character to cut at
+	older := strings.Replace(truncatedMessage, "|P|2.7", "|P|2.5", 1)
+	path, err1 := ParsePath("OBX-5")
+	resp, err2 := AbstractHL7Opts(older, path, WithTruncation())
+	expectValue(t, "Patient reports#", resp, err1, err2)
+
+	// a message that declares no version gets the benefit of the doubt
+	unversioned := strings.Replace(truncatedMessage, "|P|2.7", "|P|", 1)
+	resp, err2 = AbstractHL7Opts(unversioned, path, WithTruncation())
+	expectValue(t, "Patient reports", resp, err1, err2)
+
+	spec := MessageSpec{Segments: []SegmentSpec{{Name: "MSH", Min: 1}, {Name: "PID", Min: 1}}}
+	expectValue(t, nil, ValidateMessage(truncatedMessage, spec))
+	expectValue(t, nil, ValidateMessage(unversioned, spec))
+	err := ValidateMessage(older, spec)
+	expectError(t, err, "truncation character # requires HL7 2.7 or later, the message is 2.5")
+	expectValue(t, true, errors.Is(err, ErrInvalidMessage))
+}
@@ -0,0 +1,111 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+)
+
+// walkLeaves calls fn with the path and value of every populated leaf in the
+// message. A leaf is a subcomponent when the component has subcomponents,
+// otherwise the component itself. MSH-1 and MSH-2 are not component
+// structured so they are visited as whole fields.
+func walkLeaves(message string, fn func(path HL7Path, value string)) error {
+	sep, err := ParseEncoding(message)
+	if err != nil {
+		return err
+	}
+	occurrences := map[string]int{}
+	for _, segment := range segmentLines(message) {
+		fields := strings.Split(segment, string(sep.Field))
+		name := fields[0]
+		occurrences[name]++
+		path := HL7Path{Segment: name, SegmentIndex: occurrences[name]}
+		if name == "MSH" {
+			fields = append(fields[:1], append([]string{string(sep.Field)}, fields[1:]...)...)
+		}
+		for f := 1; f < len(fields); f++ {
+			path.Field = f
+			if name == "MSH" && f <= 2 {
+				path.RepetitionIndex = 1
+				path.Component, path.Subcomponent = 0, 0
+				fn(path, fields[f])
+				continue
+			}
+			if fields[f] == "" {
+				continue
+			}
+			for r, repetition := range strings.Split(fields[f], string(sep.Repetition)) {
+				path.RepetitionIndex = r + 1
+				for c, component := range splitEscaped(repetition, sep.Component, sep.Escape) {
+					path.Component = c + 1
+					path.Subcomponent = 0
+					subcomponents := splitEscaped(component, sep.Subcomponent, sep.Escape)
+					if len(subcomponents) == 1 {
+						if component != "" {
+							fn(path, component)
+						}
+						continue
+					}
+					for s, subcomponent := range subcomponents {
+						path.Subcomponent = s + 1
+						if subcomponent != "" {
+							fn(path, subcomponent)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// LeafCount returns the number of populated leaf values in a message, that is
+// every non-empty component, or subcomponent where a component has them. It
+// is a cheap measure of how large a message is. Only a bad header is an
+// error.
+func LeafCount(message string) (int, error) {
+	count := 0
+	err := walkLeaves(message, func(HL7Path, string) {
+		count++
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Flatten returns every populated leaf of a message, see LeafCount, keyed by
+// its path, like "PID-5[1].1": "EVERYWOMAN". Keys always spell out the
+// repetition and only spell out the segment index past the first occurrence,
+// "OBX[2]-5[1]", except MSH-1 and MSH-2 which don't repeat. Every key parses
+// with ParsePath back to the path of its value, so two messages can be
+// compared field by field. Values are as they are in the message, not
+// unescaped. Only a bad header is an error.
+func Flatten(message string) (map[string]string, error) {
+	leaves := map[string]string{}
+	err := walkLeaves(message, func(path HL7Path, value string) {
+		leaves[flattenKey(path)] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// flattenKey writes path like String but with the repetition of a field
+// always given.
+func flattenKey(path HL7Path) string {
+	if path.Segment == "MSH" && path.Field <= 2 {
+		return path.String()
+	}
+	var b strings.Builder
+	b.WriteString(path.Segment)
+	if path.SegmentIndex != 1 {
+		fmt.Fprintf(&b, "[%d]", path.SegmentIndex)
+	}
+	fmt.Fprintf(&b, "-%d[%d].%d", path.Field, path.RepetitionIndex, path.Component)
+	if path.Subcomponent != 0 {
+		fmt.Fprintf(&b, ".%d", path.Subcomponent)
+	}
+	return b.String()
+}
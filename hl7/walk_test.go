@@ -0,0 +1,48 @@
+package hl7
+
+import "testing"
+
+func TestWalkLeaves(t *testing.T) {
+	var visited []string
+	err := walkLeaves("MSH|^~\\&|HIS|RIH\rPID|||123^^^^MRN~456&7||DOE^JANE\r", func(path HL7Path, value string) {
+		visited = append(visited, path.Segment+" "+value)
+	})
+	expectDeepValue(t, []string{
+		"MSH |", "MSH ^~\\&", "MSH HIS", "MSH RIH",
+		"PID 123", "PID MRN", "PID 456", "PID 7", "PID DOE", "PID JANE",
+	}, visited, err)
+}
+
+func TestLeafCount(t *testing.T) {
+	count, err := LeafCount("MSH|^~\\&|HIS|RIH\rPID|||123^^^^MRN~456&7||DOE^JANE\r")
+	expectValue(t, 10, count, err)
+
+	count, err = LeafCount(message)
+	expectValue(t, 70, count, err)
+
+	_, err = LeafCount("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
+
+func TestFlatten(t *testing.T) {
+	leaves, err := Flatten("MSH|^~\\&|HIS|RIH\rPID|||123^^^^MRN~456&7||DOE^JANE\rOBX|1\rOBX|2||^Weight\r")
+	expectDeepValue(t, map[string]string{
+		"MSH-1": "|", "MSH-2": "^~\\&", "MSH-3[1].1": "HIS", "MSH-4[1].1": "RIH",
+		"PID-3[1].1": "123", "PID-3[1].5": "MRN", "PID-3[2].1.1": "456", "PID-3[2].1.2": "7",
+		"PID-5[1].1": "DOE", "PID-5[1].2": "JANE",
+		"OBX-1[1].1": "1", "OBX[2]-1[1].1": "2", "OBX[2]-3[1].2": "Weight",
+	}, leaves, err)
+
+	// every key is the path to its value
+	leaves, err = Flatten(message)
+	expectValue(t, 70, len(leaves), err)
+	expectValue(t, "EVERYWOMAN", leaves["PID-5[1].1"])
+	for key, value := range leaves {
+		path, err1 := ParsePath(key)
+		resp, err2 := AbstractHL7(message, path)
+		expectValue(t, value, resp, err1, err2)
+	}
+
+	_, err = Flatten("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+}
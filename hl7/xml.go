@@ -0,0 +1,188 @@
+package hl7
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToXML converts a message to XML following the naming of HL7 v2.xml, like
+//
+//	<ADT_A01><MSH><MSH.1>|</MSH.1>...</MSH><PID>...<PID.5><PID.5.1>EVERYWOMAN</PID.5.1><PID.5.2>EVE</PID.5.2></PID.5>...</PID></ADT_A01>
+//
+// The root element is the message structure of MSH-9.3, or the message code
+// and trigger event joined by an underscore when MSH-9.3 is empty, and
+// HL7Message when MSH-9 is empty. Every segment is an element under it, in
+// message order, so a repeated segment is a repeated element.
+//
+// A field is an element named like PID.5 and a field with several
+// repetitions is that element repeated, an empty repetition between others is
+// an empty element. A repetition without components holds its value,
+// otherwise it holds an element per component, PID.5.1, and the same goes
+// for components with subcomponents, PID.5.1.1. Empty fields, components and
+// subcomponents are left out, the position in the element name says where
+// the others are. MSH-1 and MSH-2 hold the encoding characters.
+//
+// Values are unescaped, see Unescape, and escaped for XML. The output has no
+// XML declaration, prepend xml.Header if one is needed.
+func ToXML(message string) ([]byte, error) {
+	m, err := Parse(message)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range m.Segments {
+		if _, err := parseSegmentNameOrError(segment.Name); err != nil {
+			return nil, fmt.Errorf("segment %q: %w", segment.Name, err)
+		}
+	}
+
+	var b bytes.Buffer
+	root := m.xmlRootName()
+	writeXMLStart(&b, root)
+	for _, segment := range m.Segments {
+		m.writeSegmentXML(&b, segment)
+	}
+	writeXMLEnd(&b, root)
+	return b.Bytes(), nil
+}
+
+// xmlRootName names the root element after MSH-9, see ToXML.
+func (m *Message) xmlRootName() string {
+	const fallback = "HL7Message"
+	if len(m.Segments) == 0 || len(m.Segments[0].Fields) < 9 {
+		return fallback
+	}
+	typ := m.Segments[0].Fields[8][0]
+	component := func(n int) string {
+		if n > len(typ) {
+			return ""
+		}
+		return typ[n-1][0]
+	}
+	name := component(3)
+	if name == "" && component(1) != "" {
+		name = component(1)
+		if component(2) != "" {
+			name += "_" + component(2)
+		}
+	}
+	if !isXMLName(name) {
+		return fallback
+	}
+	return name
+}
+
+// isXMLName reports whether s is a letter followed by letters, digits or
+// underscores, the names MSH-9 can make that are safe to use as an element.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, char := range s {
+		letter := (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')
+		if !letter && (i == 0 || ((char < '0' || char > '9') && char != '_')) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Message) writeSegmentXML(b *bytes.Buffer, segment Segment) {
+	writeXMLStart(b, segment.Name)
+	for i, field := range segment.Fields {
+		name := segment.Name + "." + strconv.Itoa(i+1)
+		if segment.Name == "MSH" && i < 2 {
+			// the encoding characters are not structured or escaped
+			var raw strings.Builder
+			m.encodeRepetition(&raw, field[0])
+			writeXMLValue(b, name, raw.String())
+			continue
+		}
+		if isEmptyField(field) {
+			continue
+		}
+		for _, repetition := range field {
+			m.writeRepetitionXML(b, name, repetition)
+		}
+	}
+	writeXMLEnd(b, segment.Name)
+}
+
+func (m *Message) writeRepetitionXML(b *bytes.Buffer, name string, repetition Repetition) {
+	if len(repetition) == 1 || isEmptyField(Field{repetition}) {
+		m.writeComponentXML(b, name, repetition[0])
+		return
+	}
+	writeXMLStart(b, name)
+	for c, component := range repetition {
+		if isEmptyComponent(component) {
+			continue
+		}
+		m.writeComponentXML(b, name+"."+strconv.Itoa(c+1), component)
+	}
+	writeXMLEnd(b, name)
+}
+
+func (m *Message) writeComponentXML(b *bytes.Buffer, name string, component Component) {
+	if len(component) == 1 || isEmptyComponent(component) {
+		writeXMLValue(b, name, Unescape(component[0], m.Encoding))
+		return
+	}
+	writeXMLStart(b, name)
+	for s, subcomponent := range component {
+		if subcomponent == "" {
+			continue
+		}
+		writeXMLValue(b, name+"."+strconv.Itoa(s+1), Unescape(subcomponent, m.Encoding))
+	}
+	writeXMLEnd(b, name)
+}
+
+func isEmptyField(field Field) bool {
+	for _, repetition := range field {
+		for _, component := range repetition {
+			if !isEmptyComponent(component) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isEmptyComponent(component Component) bool {
+	for _, subcomponent := range component {
+		if subcomponent != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func writeXMLStart(b *bytes.Buffer, name string) {
+	b.WriteByte('<')
+	b.WriteString(name)
+	b.WriteByte('>')
+}
+
+func writeXMLEnd(b *bytes.Buffer, name string) {
+	b.WriteString("</")
+	b.WriteString(name)
+	b.WriteByte('>')
+}
+
+// writeXMLValue writes an element holding value, or an empty element when
+// value is empty.
+func writeXMLValue(b *bytes.Buffer, name string, value string) {
+	if value == "" {
+		b.WriteByte('<')
+		b.WriteString(name)
+		b.WriteString("/>")
+		return
+	}
+	writeXMLStart(b, name)
+	// EscapeText only fails when the writer does
+	_ = xml.EscapeText(b, []byte(value))
+	writeXMLEnd(b, name)
+}
@@ -0,0 +1,54 @@
+package hl7
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestToXML(t *testing.T) {
+	data, err := ToXML(message)
+	expectValue(t, nil, err)
+
+	// the root is the message type, MSH-1 and MSH-2 are the encoding
+	// characters and empty fields are left out
+	expectValue(t, true, strings.HasPrefix(string(data), `<ADT_A01><MSH><MSH.1>|</MSH.1><MSH.2>^~\&amp;</MSH.2><MSH.3>HIS</MSH.3><MSH.4>RIH</MSH.4><MSH.5>EKG</MSH.5><MSH.6>EKG</MSH.6><MSH.7>20060529090131</MSH.7><MSH.9><MSH.9.1>ADT</MSH.9.1><MSH.9.2>A01</MSH.9.2></MSH.9><MSH.10>MSG00001</MSH.10>`))
+	expectValue(t, true, strings.HasSuffix(string(data), `</ADT_A01>`))
+	// repetitions are repeated elements, empty components are left out
+	expectValue(t, true, strings.Contains(string(data), `<PID.3><PID.3.1>555-44-4444</PID.3.1><PID.3.5>SSN</PID.3.5></PID.3><PID.3><PID.3.1>123</PID.3.1><PID.3.5>MRN</PID.3.5></PID.3>`))
+	expectValue(t, true, strings.Contains(string(data), `<PID.5><PID.5.1>EVERYWOMAN</PID.5.1><PID.5.2>EVE</PID.5.2><PID.5.3>E</PID.5.3><PID.5.7>L</PID.5.7></PID.5>`))
+	expectValue(t, true, strings.Contains(string(data), `<PID.7>19610615</PID.7><PID.8>F</PID.8><PID.10>C</PID.10>`))
+	// repeated segments are repeated elements and subcomponents nest
+	expectValue(t, 2, strings.Count(string(data), "<OBX>"))
+	expectValue(t, true, strings.Contains(string(data), `<ZZZ><ZZZ.2>This is</ZZZ.2><ZZZ.2><ZZZ.2.1>a</ZZZ.2.1><ZZZ.2.2><ZZZ.2.2.1>custom</ZZZ.2.2.1><ZZZ.2.2.2>segment</ZZZ.2.2.2><ZZZ.2.2.3>with</ZZZ.2.2.3></ZZZ.2.2>`))
+
+	// the output is well formed
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err = decoder.Token(); err != nil {
+			break
+		}
+	}
+	expectValue(t, "EOF", err.Error())
+}
+
+func TestToXMLEscapes(t *testing.T) {
+	// values are unescaped, then escaped for XML, empty repetitions between
+	// others are kept
+	data, err := ToXML("MSH#!@$%#HIS#\"Q\"\rPID#1#A$F$B<C>&D$X0D0A$@@X")
+	expectValue(t, `<HL7Message><MSH><MSH.1>#</MSH.1><MSH.2>!@$%</MSH.2><MSH.3>HIS</MSH.3><MSH.4>&#34;Q&#34;</MSH.4></MSH><PID><PID.1>1</PID.1><PID.2>A#B&lt;C&gt;&amp;D&#xD;&#xA;</PID.2><PID.2/><PID.2>X</PID.2></PID></HL7Message>`, string(data), err)
+
+	// the message structure names the root, or the code and trigger event
+	data, err = ToXML("MSH|^~\\&|||||||ORU^R01^ORU_R01\rOBX|1")
+	expectValue(t, true, strings.HasPrefix(string(data), "<ORU_R01>"), err)
+	data, err = ToXML("MSH|^~\\&|||||||ACK\rMSA|AA")
+	expectValue(t, true, strings.HasPrefix(string(data), "<ACK>"), err)
+	data, err = ToXML("MSH|^~\\&|||||||A-B")
+	expectValue(t, true, strings.HasPrefix(string(data), "<HL7Message>"), err)
+
+	_, err = ToXML("PID|1")
+	expectError(t, err, "invalid HL7 message: must begin with MSH")
+	_, err = ToXML("MSH|^~\\&|HIS\rpid|1")
+	expectError(t, err, `segment "pid": segment name must begin with an uppercase letter`)
+}